@@ -0,0 +1,245 @@
+package lmclient
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/netip"
+	"strconv"
+)
+
+// ACLList selects the global or per Virtual Service access list an address
+// is added to: addresses on the block list are refused, and once the allow
+// list is not empty only addresses on it are accepted.
+type ACLList string
+
+const (
+	ACLBlock ACLList = "black"
+	ACLAllow ACLList = "white"
+)
+
+type ACLs struct {
+	XMLName   xml.Name   `xml:"Response"`
+	Blacklist []ACLEntry `xml:"Success>Data>Blacklist>IP"`
+	Whitelist []ACLEntry `xml:"Success>Data>Whitelist>IP"`
+}
+
+// ACLEntry is an address or CIDR network on an access list.
+type ACLEntry struct {
+	Addr    string `json:"addr" xml:"addr"`
+	Comment string `json:"comment" xml:"comment"`
+}
+
+// NormalizeACLAddr checks that addr is an IP address or CIDR network and
+// returns it in the form used to compare access list entries: networks
+// have their host bits cleared, and networks of a single address are
+// written as plain address.
+func NormalizeACLAddr(addr string) (string, error) {
+	if ip, err := netip.ParseAddr(addr); err == nil {
+		return ip.String(), nil
+	}
+	prefix, err := netip.ParsePrefix(addr)
+	if err != nil {
+		return "", fmt.Errorf("Invalid ACL address: %s", addr)
+	}
+	prefix = prefix.Masked()
+	if prefix.IsSingleIP() {
+		return prefix.Addr().String(), nil
+	}
+	return prefix.String(), nil
+}
+
+// aclCommand sends aclcontrol, which takes the operation as the name of
+// the parameter holding the list. The per Virtual Service operations also
+// take the address, port and protocol of the Virtual Service vs.
+func (c *Client) aclCommand(op string, list ACLList, vs *Vs, addr string, comment string, v interface{}) error {
+	cmd := "aclcontrol"
+	payload := struct {
+		CMD     string `json:"cmd" qs:"-"`
+		Add     string `json:"add,omitempty" qs:"add,omitempty"`
+		Del     string `json:"del,omitempty" qs:"del,omitempty"`
+		List    string `json:"list,omitempty" qs:"list,omitempty"`
+		AddVs   string `json:"addvs,omitempty" qs:"addvs,omitempty"`
+		DelVs   string `json:"delvs,omitempty" qs:"delvs,omitempty"`
+		ListVs  string `json:"listvs,omitempty" qs:"listvs,omitempty"`
+		VsIP    string `json:"vsip,omitempty" qs:"vsip,omitempty"`
+		VsPort  string `json:"vsport,omitempty" qs:"vsport,omitempty"`
+		VsProt  string `json:"vsprot,omitempty" qs:"vsprot,omitempty"`
+		Addr    string `json:"addr,omitempty" qs:"addr,omitempty"`
+		Comment string `json:"comment,omitempty" qs:"comment,omitempty"`
+	}{
+		CMD:     cmd,
+		Addr:    addr,
+		Comment: comment,
+	}
+
+	if vs != nil {
+		port := vs.VSPort
+		if port == "" {
+			port = vs.Port
+		}
+		if _, err := strconv.Atoi(port); err != nil {
+			return fmt.Errorf("Invalid Virtual Service port: %s", port)
+		}
+		payload.VsIP, payload.VsPort, payload.VsProt = vs.Address, port, vs.Protocol
+		op += "vs"
+	}
+	switch op {
+	case "add":
+		payload.Add = string(list)
+	case "del":
+		payload.Del = string(list)
+	case "list":
+		payload.List = string(list)
+	case "addvs":
+		payload.AddVs = string(list)
+	case "delvs":
+		payload.DelVs = string(list)
+	case "listvs":
+		payload.ListVs = string(list)
+	}
+
+	if v == nil {
+		_, err := c.sendApiCommand(cmd, payload)
+		return err
+	}
+	return c.sendCommand(cmd, payload, v)
+}
+
+func (c *Client) listACL(list ACLList, vs *Vs) ([]ACLEntry, error) {
+	var acls ACLs
+	if err := c.aclCommand("list", list, vs, "", "", &acls); err != nil {
+		return nil, err
+	}
+	if list == ACLAllow {
+		return acls.Whitelist, nil
+	}
+	return acls.Blacklist, nil
+}
+
+// addACL adds the addresses in addrs to list, stopping at the first
+// failure. All addresses are validated before any is added.
+func (c *Client) addACL(list ACLList, vs *Vs, addrs []string, comment string) error {
+	normalized, err := normalizeACLAddrs(addrs)
+	if err != nil {
+		return err
+	}
+	for _, addr := range normalized {
+		if err := c.aclCommand("add", list, vs, addr, comment, nil); err != nil {
+			return fmt.Errorf("Adding %s to %s list failed: %w", addr, list, err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) deleteACL(list ACLList, vs *Vs, addrs []string) error {
+	normalized, err := normalizeACLAddrs(addrs)
+	if err != nil {
+		return err
+	}
+	for _, addr := range normalized {
+		if err := c.aclCommand("del", list, vs, addr, "", nil); err != nil {
+			return fmt.Errorf("Removing %s from %s list failed: %w", addr, list, err)
+		}
+	}
+	return nil
+}
+
+// syncACL makes list contain exactly addrs. Entries on the appliance are
+// removed using the address they were listed with, as it may not be in
+// normalized form.
+func (c *Client) syncACL(list ACLList, vs *Vs, addrs []string, comment string) (bool, error) {
+	normalized, err := normalizeACLAddrs(addrs)
+	if err != nil {
+		return false, err
+	}
+	current, err := c.listACL(list, vs)
+	if err != nil {
+		return false, err
+	}
+
+	want := make(map[string]bool, len(normalized))
+	for _, addr := range normalized {
+		want[addr] = true
+	}
+
+	changed := false
+	have := make(map[string]bool, len(current))
+	for _, e := range current {
+		addr, err := NormalizeACLAddr(e.Addr)
+		if err == nil && want[addr] {
+			have[addr] = true
+			continue
+		}
+		if err := c.aclCommand("del", list, vs, e.Addr, "", nil); err != nil {
+			return changed, fmt.Errorf("Removing %s from %s list failed: %w", e.Addr, list, err)
+		}
+		changed = true
+	}
+	for _, addr := range normalized {
+		if have[addr] {
+			continue
+		}
+		if err := c.aclCommand("add", list, vs, addr, comment, nil); err != nil {
+			return changed, fmt.Errorf("Adding %s to %s list failed: %w", addr, list, err)
+		}
+		have[addr] = true
+		changed = true
+	}
+	return changed, nil
+}
+
+// normalizeACLAddrs normalizes addrs with NormalizeACLAddr, dropping
+// duplicates.
+func normalizeACLAddrs(addrs []string) ([]string, error) {
+	seen := make(map[string]bool, len(addrs))
+	var res []string
+	for _, addr := range addrs {
+		n, err := NormalizeACLAddr(addr)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[n] {
+			seen[n] = true
+			res = append(res, n)
+		}
+	}
+	return res, nil
+}
+
+// ListACL returns the entries of the global access list.
+func (c *Client) ListACL(list ACLList) ([]ACLEntry, error) {
+	return c.listACL(list, nil)
+}
+
+// AddACL adds IP addresses or CIDR networks to the global access list.
+func (c *Client) AddACL(list ACLList, addrs []string, comment string) error {
+	return c.addACL(list, nil, addrs, comment)
+}
+
+func (c *Client) DeleteACL(list ACLList, addrs []string) error {
+	return c.deleteACL(list, nil, addrs)
+}
+
+// SyncACL makes the global access list contain exactly addrs and reports
+// whether anything had to be changed. Added entries get comment.
+func (c *Client) SyncACL(list ACLList, addrs []string, comment string) (bool, error) {
+	return c.syncACL(list, nil, addrs, comment)
+}
+
+// ListVsACL returns the entries of the access list of the Virtual Service
+// vs, which is identified by its Address, VSPort (or Port) and Protocol.
+func (c *Client) ListVsACL(vs *Vs, list ACLList) ([]ACLEntry, error) {
+	return c.listACL(list, vs)
+}
+
+func (c *Client) AddVsACL(vs *Vs, list ACLList, addrs []string, comment string) error {
+	return c.addACL(list, vs, addrs, comment)
+}
+
+func (c *Client) DeleteVsACL(vs *Vs, list ACLList, addrs []string) error {
+	return c.deleteACL(list, vs, addrs)
+}
+
+func (c *Client) SyncVsACL(vs *Vs, list ACLList, addrs []string, comment string) (bool, error) {
+	return c.syncACL(list, vs, addrs, comment)
+}
@@ -0,0 +1,128 @@
+package lmclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newACLServer answers aclcontrol, recording the operations received as
+// "<op>=<list> <addr>".
+func newACLServer(t *testing.T, apiversion int, ops *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		params := map[string]string{}
+		if apiversion == 1 {
+			equals(t, "/access/aclcontrol", req.URL.Path)
+			for k, v := range req.URL.Query() {
+				params[k] = v[0]
+			}
+		} else {
+			body, err := ioutil.ReadAll(req.Body)
+			ok(t, err)
+			var payload map[string]interface{}
+			ok(t, json.Unmarshal(body, &payload))
+			equals(t, "aclcontrol", payload["cmd"])
+			for k, v := range payload {
+				params[k] = fmt.Sprint(v)
+			}
+		}
+
+		name := "ok"
+		for _, op := range []string{"add", "del", "list", "addvs", "delvs", "listvs"} {
+			if list, found := params[op]; found {
+				*ops = append(*ops, fmt.Sprintf("%s=%s %s", op, list, params["addr"]))
+				if op == "list" || op == "listvs" {
+					name = "aclcontrol"
+				}
+			}
+		}
+		if params["vsip"] != "" {
+			equals(t, "192.168.1.10 443 tcp", params["vsip"]+" "+params["vsport"]+" "+params["vsprot"])
+		}
+
+		ext := ".json"
+		if apiversion == 1 {
+			ext = ".xml"
+		}
+		content, err := ioutil.ReadFile("test_data/" + name + ext)
+		ok(t, err)
+		_, err = rw.Write(content)
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+}
+
+func TestNormalizeACLAddr(t *testing.T) {
+	testCases := []struct {
+		addr string
+		exp  string
+	}{
+		{"10.1.2.3", "10.1.2.3"},
+		{"10.1.2.3/8", "10.0.0.0/8"},
+		{"10.1.2.3/32", "10.1.2.3"},
+		{"2001:db8::1/48", "2001:db8::/48"},
+		{"10.1.2.300", ""},
+		{"example.com", ""},
+	}
+	for _, tc := range testCases {
+		addr, err := NormalizeACLAddr(tc.addr)
+		if tc.exp == "" {
+			equals(t, "Invalid ACL address: "+tc.addr, err.Error())
+			continue
+		}
+		ok(t, err)
+		equals(t, tc.exp, addr)
+	}
+}
+
+func TestSyncACL(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var ops []string
+			server := newACLServer(t, apiversion, &ops)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			entries, err := client.ListACL(ACLBlock)
+			ok(t, err)
+			equals(t, []ACLEntry{{Addr: "10.0.0.0/8"}, {Addr: "192.168.7.1", Comment: "incident 42"}}, entries)
+
+			ops = nil
+			changed, err := client.SyncACL(ACLBlock, []string{"10.2.3.4/8", "192.168.7.1/32"}, "")
+			ok(t, err)
+			equals(t, false, changed)
+			equals(t, []string{"list=black "}, ops)
+
+			ops = nil
+			changed, err = client.SyncACL(ACLBlock, []string{"10.0.0.0/8", "172.16.0.0/12"}, "incident 43")
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, []string{"list=black ", "del=black 192.168.7.1", "add=black 172.16.0.0/12"}, ops)
+
+			ops = nil
+			err = client.AddACL(ACLBlock, []string{"172.16.0.0/12", "bogus"}, "")
+			equals(t, "Invalid ACL address: bogus", err.Error())
+			equals(t, 0, len(ops))
+		})
+	}
+}
+
+func TestVsACL(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var ops []string
+			server := newACLServer(t, apiversion, &ops)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+			vs := &Vs{Address: "192.168.1.10", VSPort: "443", Protocol: "tcp"}
+
+			ok(t, client.AddVsACL(vs, ACLAllow, []string{"10.0.0.1", "10.0.0.1/32", "10.0.1.0/24"}, ""))
+			ok(t, client.DeleteVsACL(vs, ACLAllow, []string{"10.0.0.1"}))
+			equals(t, []string{"addvs=white 10.0.0.1", "addvs=white 10.0.1.0/24", "delvs=white 10.0.0.1"}, ops)
+		})
+	}
+}
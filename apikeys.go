@@ -0,0 +1,130 @@
+package lmclient
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+type ApiKeys struct {
+	XMLName xml.Name `xml:"Response"`
+	ApiKey  []string `xml:"Success>Data>ApiKey"`
+}
+
+type newApiKey struct {
+	XMLName xml.Name `xml:"Response"`
+	ApiKey  string   `xml:"Success>Data>ApiKey"`
+}
+
+// GenerateApiKey creates a new API key for the user the client
+// authenticates as.
+func (c *Client) GenerateApiKey() (string, error) {
+	cmd := "addapikey"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var key newApiKey
+	if err := c.sendCommand(cmd, payload, &key); err != nil {
+		return "", err
+	}
+	if key.ApiKey == "" {
+		return "", errors.New("No API key in response")
+	}
+	return key.ApiKey, nil
+}
+
+func (c *Client) ListApiKeys() ([]string, error) {
+	cmd := "listapikeys"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var keys ApiKeys
+	if err := c.sendCommand(cmd, payload, &keys); err != nil {
+		return nil, err
+	}
+	return keys.ApiKey, nil
+}
+
+// DeleteApiKey revokes key. Errors are returned with key masked, as it may
+// differ from the key of the client.
+func (c *Client) DeleteApiKey(key string) (*ApiResponse, error) {
+	cmd := "delapikey"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+		Key string `json:"key" qs:"key"`
+	}{
+		CMD: cmd,
+		Key: key,
+	}
+
+	ar, err := c.sendApiCommand(cmd, payload)
+	if err != nil {
+		return ar, c.redactError(err, key)
+	}
+	return ar, nil
+}
+
+// RotateApiKey replaces the API key of the client. A new key is generated
+// and checked with a separate client before it is swapped in, after which
+// the old key is revoked. The new key is returned even if revoking the old
+// one fails, as the client is already using it by then. Clients using a
+// CredentialsProvider need one implementing ApiKeySetter, so the provider
+// hands out the new key afterwards.
+func (c *Client) RotateApiKey() (string, error) {
+	var setter ApiKeySetter
+	if c.CredentialsProvider != nil {
+		var ok bool
+		setter, ok = c.CredentialsProvider.(ApiKeySetter)
+		if !ok {
+			return "", errors.New("Credentials provider can not store a new API key")
+		}
+	}
+
+	creds, err := c.credentials()
+	if err != nil {
+		return "", err
+	}
+	oldKey := creds.ApiKey
+
+	newKey, err := c.GenerateApiKey()
+	if err != nil {
+		return "", err
+	}
+
+	fresh := &Client{
+		HttpClient: c.HttpClient,
+		ApiKey:     newKey,
+		RestUrl:    c.RestUrl,
+		Version:    c.Version,
+	}
+	if _, err := fresh.ListApiKeys(); err != nil {
+		if _, derr := c.DeleteApiKey(newKey); derr != nil {
+			return "", fmt.Errorf("New API key not working: %v, removing it failed: %v", err, derr)
+		}
+		return "", fmt.Errorf("New API key not working: %v", err)
+	}
+
+	if setter != nil {
+		if err := setter.SetApiKey(newKey); err != nil {
+			if _, derr := c.DeleteApiKey(newKey); derr != nil {
+				return "", fmt.Errorf("Storing new API key failed: %v, removing it failed: %v", err, derr)
+			}
+			return "", fmt.Errorf("Storing new API key failed: %v", err)
+		}
+	} else {
+		c.SetApiKey(newKey)
+	}
+
+	if oldKey != "" {
+		if _, err := c.DeleteApiKey(oldKey); err != nil {
+			return newKey, fmt.Errorf("Revoking old API key failed: %v", err)
+		}
+	}
+	return newKey, nil
+}
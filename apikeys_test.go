@@ -0,0 +1,165 @@
+package lmclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newApiKeyServer starts a local HTTP server accepting and managing the API
+// keys in keys. addapikey always generates "newkey".
+func newApiKeyServer(t *testing.T, apiversion int, keys *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var cmd, apikey, key string
+		if apiversion == 1 {
+			cmd = strings.TrimPrefix(req.URL.Path, "/access/")
+			apikey = req.Header.Get(ApiKeyHeader)
+			key = req.URL.Query().Get("key")
+		} else {
+			body, err := ioutil.ReadAll(req.Body)
+			ok(t, err)
+			var payload struct {
+				CMD    string `json:"cmd"`
+				ApiKey string `json:"apikey"`
+				Key    string `json:"key"`
+			}
+			ok(t, json.Unmarshal(body, &payload))
+			cmd, apikey, key = payload.CMD, payload.ApiKey, payload.Key
+		}
+
+		known := false
+		for _, k := range *keys {
+			known = known || k == apikey
+		}
+		var data string
+		switch {
+		case !known:
+			rw.WriteHeader(http.StatusUnauthorized)
+		case cmd == "addapikey":
+			*keys = append(*keys, "newkey")
+			data = "newkey"
+		case cmd == "listapikeys":
+			data = strings.Join(*keys, ",")
+		case cmd == "delapikey":
+			for i, k := range *keys {
+				if k == key {
+					*keys = append((*keys)[:i], (*keys)[i+1:]...)
+					break
+				}
+			}
+		}
+
+		var resp string
+		if apiversion == 1 {
+			resp = `<?xml version="1.0" encoding="ISO-8859-1"?>
+<Response stat="200" code="ok">
+<Success><Data><ApiKey>` + data + `</ApiKey></Data></Success>
+</Response>`
+		} else if cmd == "listapikeys" {
+			resp = `{ "code": 200, "ApiKey": ["` + strings.Join(*keys, `","`) + `"], "status": "ok" }`
+		} else {
+			resp = `{ "code": 200, "ApiKey": "` + data + `", "status": "ok" }`
+		}
+		_, err := rw.Write([]byte(resp))
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+}
+
+func TestRotateApiKey(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			keys := []string{"bar"}
+			server := newApiKeyServer(t, apiversion, &keys)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			key, err := client.RotateApiKey()
+			ok(t, err)
+
+			equals(t, "newkey", key)
+			equals(t, "newkey", client.ApiKey)
+			equals(t, []string{"newkey"}, keys)
+		})
+	}
+}
+
+func TestDeleteApiKeyRedaction(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			// Start a local HTTP server echoing the request in its error
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				body, err := ioutil.ReadAll(req.Body)
+				ok(t, err)
+				rw.WriteHeader(http.StatusInternalServerError)
+				_, err = rw.Write([]byte("cannot revoke in " + req.URL.String() + " " + string(body)))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "newkey", RestUrl: server.URL, Version: apiversion}
+
+			_, err := client.DeleteApiKey("0ldk3y")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			equals(t, false, strings.Contains(err.Error(), "0ldk3y"))
+			equals(t, true, strings.Contains(err.Error(), "cannot revoke"))
+		})
+	}
+}
+
+// settableProvider is a provider storing a new API key in memory.
+type settableProvider struct {
+	mu    sync.Mutex
+	creds Credentials
+}
+
+func (p *settableProvider) Credentials() (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.creds, nil
+}
+
+func (p *settableProvider) SetApiKey(apiKey string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.creds.ApiKey = apiKey
+	return nil
+}
+
+func TestRotateApiKeyProvider(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			keys := []string{"bar"}
+			server := newApiKeyServer(t, apiversion, &keys)
+			defer server.Close()
+
+			// A provider that can not store the key must not lose the old one
+			client := Client{HttpClient: server.Client(), CredentialsProvider: StaticProvider{ApiKey: "bar"}, RestUrl: server.URL, Version: apiversion}
+			_, err := client.RotateApiKey()
+			equals(t, "Credentials provider can not store a new API key", err.Error())
+			equals(t, []string{"bar"}, keys)
+
+			provider := &settableProvider{creds: Credentials{ApiKey: "bar"}}
+			client.CredentialsProvider = provider
+			key, err := client.RotateApiKey()
+			ok(t, err)
+			equals(t, "newkey", key)
+			equals(t, []string{"newkey"}, keys)
+
+			creds, err := provider.Credentials()
+			ok(t, err)
+			equals(t, "newkey", creds.ApiKey)
+			_, err = client.ListApiKeys()
+			ok(t, err)
+		})
+	}
+}
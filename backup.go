@@ -0,0 +1,72 @@
+package lmclient
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// RestoreScope selects which parts of a backup Restore applies. Scopes can
+// be combined, e.g. RestoreBase | RestoreVs.
+type RestoreScope int
+
+const (
+	RestoreBase RestoreScope = 1 << iota
+	RestoreVs
+	RestoreGeo
+	RestoreCerts
+)
+
+// Backup fetches a backup archive of the LoadMaster configuration. The
+// archive is streamed from the LoadMaster and the caller must close it.
+func (c *Client) Backup(ctx context.Context) (io.ReadCloser, error) {
+	cmd := "backup"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	req, err := c.newRequestWithContext(ctx, cmd, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.doStreamRequest(req)
+}
+
+// Restore uploads a backup archive read from r and restores the parts of it
+// selected by scope.
+func (c *Client) Restore(ctx context.Context, r io.Reader, scope RestoreScope) (*ApiResponse, error) {
+	if scope == 0 {
+		return nil, errors.New("Restore scope must not be empty")
+	}
+
+	cmd := "restore"
+	payload := struct {
+		CMD  string `json:"cmd" qs:"-"`
+		Type int    `json:"type" qs:"type"`
+	}{
+		CMD:  cmd,
+		Type: int(scope),
+	}
+
+	req, err := c.newUploadRequest(ctx, cmd, payload, r)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(req)
+	if err != nil {
+		return nil, c.responseError(resp, err)
+	}
+
+	var ar ApiResponse
+	if err := c.decodeResponse(resp, &ar); err != nil {
+		return nil, err
+	}
+	if ar.Status != "ok" {
+		return nil, c.responseError(resp, errors.New("Restore failed"))
+	}
+	return &ar, nil
+}
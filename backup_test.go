@@ -0,0 +1,98 @@
+package lmclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBackup(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+	}{
+		{2, "/accessv2"},
+		{1, "/access/backup"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			// Start a local HTTP server
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// Test request parameters
+				equals(t, req.URL.String(), tc.url)
+				// Send response to be tested
+				_, err := rw.Write([]byte("archive"))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
+
+			r, err := client.Backup(context.Background())
+			ok(t, err)
+			defer r.Close()
+			b, err := ioutil.ReadAll(r)
+			ok(t, err)
+
+			equals(t, []byte("archive"), b)
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+		datafile   string
+	}{
+		{2, "/accessv2", "test_data/restore.json"},
+		{1, "/access/restore?type=3", "test_data/restore.xml"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			content, err := ioutil.ReadFile(tc.datafile)
+			ok(t, err)
+			// Start a local HTTP server
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// Test request parameters
+				equals(t, req.URL.String(), tc.url)
+				body, err := ioutil.ReadAll(req.Body)
+				ok(t, err)
+				if tc.apiversion == 1 {
+					equals(t, []byte("archive"), body)
+				} else {
+					var payload struct {
+						CMD  string `json:"cmd"`
+						Type int    `json:"type"`
+						Data string `json:"data"`
+					}
+					ok(t, json.Unmarshal(body, &payload))
+					equals(t, "restore", payload.CMD)
+					equals(t, 3, payload.Type)
+					equals(t, base64.StdEncoding.EncodeToString([]byte("archive")), payload.Data)
+				}
+				// Send response to be tested
+				_, err = rw.Write([]byte(content))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
+
+			ar, err := client.Restore(context.Background(), bytes.NewReader([]byte("archive")), RestoreBase|RestoreVs)
+			ok(t, err)
+
+			equals(t, ar.Status, "ok")
+		})
+	}
+}
@@ -1,13 +1,23 @@
 package lmclient
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pasztorpisti/qs"
 )
@@ -19,8 +29,57 @@ type Client struct {
 	ApiPass    string
 	RestUrl    string
 	Version    int
+
+	// CredentialsProvider, if set, is asked for the credentials of every
+	// request instead of using ApiKey, ApiUser and ApiPass.
+	CredentialsProvider CredentialsProvider
+
+	// Certificate, if set, is the client certificate the LoadMaster
+	// authenticates the client with. No other credentials are sent then.
+	Certificate *tls.Certificate
+
+	// ApiKeyInQuery sends the API key of API version 1 requests as query
+	// parameter instead of in the ApiKeyHeader header, for firmware not
+	// supporting the header. Query parameters tend to end up in logs.
+	ApiKeyInQuery bool
+
+	// PasswordInQuery allows the API version 1 commands taking a password,
+	// like AddUser and ActivateLicenseOnline, which can only send it as
+	// query parameter. They are refused on API version 1 otherwise.
+	PasswordInQuery bool
+
+	// Limiter, if set, throttles the requests of the client.
+	Limiter *Limiter
+
+	// Logger receives debug messages about every request and warnings.
+	// Without one, warnings go to the standard logger.
+	Logger Logger
+
+	// BeforeRequest, if set, is called with every request before it is
+	// sent and may return a replacement, e.g. with a tracing span added to
+	// its context. The command sent is available through Command.
+	BeforeRequest func(req *http.Request) *http.Request
+
+	// AfterResponse, if set, is called after every request with the
+	// request returned by BeforeRequest and the outcome.
+	AfterResponse func(req *http.Request, res *http.Response, err error, elapsed time.Duration)
+
+	// CacheNames makes LookupVsIndex cache the Virtual Service names, see
+	// InvalidateNameCache.
+	CacheNames bool
+
+	// mu guards the credentials so they can be swapped while requests are
+	// in flight, see SetApiKey. It also guards the cached appliance info
+	// and names, namesGen counting the invalidations of the latter.
+	mu       sync.RWMutex
+	info     *cachedApplianceInfo
+	names    map[string][]int
+	namesGen uint64
 }
 
+// ApiKeyHeader is the header carrying the API key of API version 1 requests.
+const ApiKeyHeader = "apikey"
+
 type ApiResponse struct {
 	XMLName xml.Name `xml:"Response"`
 	Code    int      `json:"code" xml:"stat,attr"`
@@ -41,19 +100,101 @@ func NewClient(apiKey string, apiUser string, apiPass string, restUrl string, ve
 	}
 }
 
-func (c *Client) newRequest(cmd string, payload interface{}) (*http.Request, error) {
-	if (c.ApiUser == "" || c.ApiPass == "") && c.ApiKey == "" {
-		err := fmt.Errorf("Missing authentication")
+// NewClientWithCertificate returns a client authenticating with a client
+// certificate instead of an API key or user and password.
+func NewClientWithCertificate(cert tls.Certificate, restUrl string, version int) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: true,
+		Certificates:       []tls.Certificate{cert},
+	}
+	return &Client{
+		HttpClient:  &http.Client{Transport: transport},
+		RestUrl:     restUrl,
+		Version:     version,
+		Certificate: &cert,
+	}
+}
+
+// NewClientWithCertFiles is like NewClientWithCertificate but loads the
+// certificate and its key from PEM files.
+func NewClientWithCertFiles(certFile string, keyFile string, restUrl string, version int) (*Client, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
 		return nil, err
 	}
+	return NewClientWithCertificate(cert, restUrl, version), nil
+}
+
+// NewClientWithProvider returns a client asking provider for the
+// credentials of every request.
+func NewClientWithProvider(provider CredentialsProvider, restUrl string, version int) *Client {
+	c := NewClient("", "", "", restUrl, version)
+	c.CredentialsProvider = provider
+	return c
+}
+
+// SetApiKey replaces the API key used for all following requests. It has
+// no effect on clients using a CredentialsProvider, set the key on the
+// provider instead.
+func (c *Client) SetApiKey(apiKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ApiKey = apiKey
+}
+
+func (c *Client) credentials() (Credentials, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.CredentialsProvider != nil {
+		return c.CredentialsProvider.Credentials()
+	}
+	return Credentials{ApiKey: c.ApiKey, ApiUser: c.ApiUser, ApiPass: c.ApiPass}, nil
+}
+
+// newRequest builds the request for cmd. The credentials are added to the
+// payload here, so command payloads only carry their own parameters.
+func (c *Client) newRequest(cmd string, payload interface{}) (*http.Request, error) {
+	return c.newRequestWithContext(context.Background(), cmd, payload)
+}
+
+func (c *Client) newRequestWithContext(ctx context.Context, cmd string, payload interface{}) (*http.Request, error) {
+	ctx = withCommand(ctx, cmd)
+	var creds Credentials
+	if c.Certificate == nil {
+		var err error
+		creds, err = c.credentials()
+		if err != nil {
+			return nil, err
+		}
+		if creds.empty() {
+			err := fmt.Errorf("Missing authentication")
+			return nil, err
+		}
+	}
+	apiKey, apiUser, apiPass := creds.ApiKey, creds.ApiUser, creds.ApiPass
 	if c.Version == 1 {
 		v, _ := qs.Marshal(payload)
-		req, err := http.NewRequest("GET", fmt.Sprintf("%s/access/%s?%s", c.RestUrl, cmd, v), nil)
+		values, err := url.ParseQuery(v)
 		if err != nil {
 			return nil, err
 		}
-		if c.ApiUser != "" && c.ApiPass != "" {
-			req.SetBasicAuth(c.ApiUser, c.ApiPass)
+		if apiKey != "" && c.ApiKeyInQuery {
+			values.Set("apikey", apiKey)
+		}
+		u := fmt.Sprintf("%s/access/%s", c.RestUrl, cmd)
+		if len(values) > 0 {
+			u += "?" + values.Encode()
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if apiKey != "" && !c.ApiKeyInQuery {
+			req.Header.Set(ApiKeyHeader, apiKey)
+		}
+		if apiUser != "" && apiPass != "" {
+			req.SetBasicAuth(apiUser, apiPass)
 		}
 		return req, nil
 	} else {
@@ -61,7 +202,20 @@ func (c *Client) newRequest(cmd string, payload interface{}) (*http.Request, err
 		if err != nil {
 			return nil, err
 		}
-		req, err := http.NewRequest("POST", fmt.Sprintf("%s/accessv2", c.RestUrl), bytes.NewBuffer(b))
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(b, &fields); err != nil {
+			return nil, err
+		}
+		for k, v := range map[string]string{"apikey": apiKey, "apiuser": apiUser, "apipass": apiPass} {
+			if v != "" {
+				fields[k], _ = json.Marshal(v)
+			}
+		}
+		b, err = json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/accessv2", c.RestUrl), bytes.NewBuffer(b))
 		if err != nil {
 			return nil, err
 		}
@@ -70,8 +224,83 @@ func (c *Client) newRequest(cmd string, payload interface{}) (*http.Request, err
 
 }
 
+// newUploadRequest builds a request for commands that take a file. API
+// version 1 posts the file as the request body, version 2 embeds it base64
+// encoded in the "data" field of the JSON payload. The file is streamed
+// rather than read into memory.
+func (c *Client) newUploadRequest(ctx context.Context, cmd string, payload interface{}, data io.Reader) (*http.Request, error) {
+	req, err := c.newRequestWithContext(ctx, cmd, payload)
+	if err != nil {
+		return nil, err
+	}
+	if c.Version == 1 {
+		req.Method = "POST"
+		req.Body = ioutil.NopCloser(data)
+		req.Header.Set("Content-Type", "application/octet-stream")
+		return req, nil
+	}
+
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimSpace(b)
+	if len(b) < 2 || b[0] != '{' || b[len(b)-1] != '}' {
+		return nil, fmt.Errorf("Payload of %s is not a JSON object", cmd)
+	}
+	head := b[:len(b)-1]
+	if len(bytes.TrimSpace(head[1:])) > 0 {
+		head = append(head, ',')
+	}
+	req.Body = newUploadBody(func(pw *io.PipeWriter) {
+		// bufio.Writer keeps the first write error and reports it on Flush
+		w := bufio.NewWriter(pw)
+		w.Write(head)
+		w.WriteString(`"data":"`)
+		enc := base64.NewEncoder(base64.StdEncoding, w)
+		_, err := io.Copy(enc, data)
+		if err == nil {
+			err = enc.Close()
+		}
+		if err == nil {
+			w.WriteString(`"}`)
+			err = w.Flush()
+		}
+		pw.CloseWithError(err)
+	})
+	req.ContentLength = -1
+	req.GetBody = nil
+	return req, nil
+}
+
+// uploadBody is a request body produced by a writer. The writer is only
+// started on the first read, so a request built but never sent leaves no
+// goroutine behind, and it stops once the body is closed.
+type uploadBody struct {
+	once  sync.Once
+	write func(pw *io.PipeWriter)
+	pr    *io.PipeReader
+	pw    *io.PipeWriter
+}
+
+func newUploadBody(write func(pw *io.PipeWriter)) *uploadBody {
+	pr, pw := io.Pipe()
+	return &uploadBody{write: write, pr: pr, pw: pw}
+}
+
+func (b *uploadBody) Read(p []byte) (int, error) {
+	b.once.Do(func() { go b.write(b.pw) })
+	return b.pr.Read(p)
+}
+
+func (b *uploadBody) Close() error {
+	// Not started after being closed
+	b.once.Do(func() {})
+	return b.pr.Close()
+}
+
 func (c *Client) doRequest(req *http.Request) ([]byte, error) {
-	res, err := c.HttpClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +312,171 @@ func (c *Client) doRequest(req *http.Request) ([]byte, error) {
 	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNoContent {
 		return body, err
 	} else {
-		return body, fmt.Errorf("status: %d, body: %s", res.StatusCode, body)
+		return body, c.redactError(fmt.Errorf("status: %d, body: %s", res.StatusCode, body))
+	}
+}
+
+// doStreamRequest is like doRequest but hands the response body to the
+// caller unread, for responses too large to hold in memory. The caller
+// must close the returned body.
+func (c *Client) doStreamRequest(req *http.Request) (io.ReadCloser, error) {
+	res, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusOK {
+		return res.Body, nil
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	return nil, c.responseError(body, c.redactError(fmt.Errorf("status: %d, body: %s", res.StatusCode, body)))
+}
+
+var (
+	yesRe = regexp.MustCompile(">Y<")
+	noRe  = regexp.MustCompile(">N<")
+)
+
+// decodeResponse decodes a LoadMaster response into v, as XML for API
+// version 1 and as JSON otherwise. Y/N element values in XML responses are
+// turned into true/false so they decode into bool fields.
+func (c *Client) decodeResponse(resp []byte, v interface{}) error {
+	if c.Version == 1 {
+		b := noRe.ReplaceAll(yesRe.ReplaceAll(resp, []byte(">true<")), []byte(">false<"))
+		decoder := xml.NewDecoder(bytes.NewReader(b))
+		decoder.CharsetReader = makeCharsetReader
+		return decoder.Decode(v)
+	}
+	return json.Unmarshal(resp, v)
+}
+
+// responseError turns the body of a failed request into an error carrying
+// the LoadMaster error code and message, falling back to err if the body
+// can not be decoded.
+func (c *Client) responseError(resp []byte, err error) error {
+	var ar ApiResponse
+	if derr := c.decodeResponse(resp, &ar); derr != nil {
+		return err
+	}
+	if c.Version == 1 {
+		ar.Message = ar.Error
+	}
+	if ar.Status != "ok" {
+		return errors.New("Code: " + fmt.Sprint(ar.Code) + " Message: " + c.redact(ar.Message))
+	}
+	return err
+}
+
+// sendCommand sends cmd with payload and decodes a successful response
+// into v.
+func (c *Client) sendCommand(cmd string, payload interface{}, v interface{}) error {
+	req, err := c.newRequest(cmd, payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doRequest(req)
+	if err != nil {
+		return c.responseError(resp, err)
 	}
+
+	return c.decodeResponse(resp, v)
+}
+
+// sendApiCommand sends cmd with payload for commands that only answer with
+// a status and message.
+func (c *Client) sendApiCommand(cmd string, payload interface{}) (*ApiResponse, error) {
+	var ar ApiResponse
+	if err := c.sendCommand(cmd, payload, &ar); err != nil {
+		return nil, err
+	}
+
+	if ar.Status != "ok" {
+		return nil, errors.New("Code: " + fmt.Sprint(ar.Code) + " Message: " + ar.Message)
+	}
+
+	return &ar, nil
+}
+
+// checkPasswordInQuery refuses sending a password as query parameter of an
+// API version 1 request unless the client allows it with PasswordInQuery.
+func (c *Client) checkPasswordInQuery() error {
+	if c.Version == 1 && !c.PasswordInQuery {
+		return errors.New("Password would be sent as query parameter with API version 1, set PasswordInQuery to allow it")
+	}
+	return nil
+}
+
+var apiKeyParamRe = regexp.MustCompile(`\b(apikey|apipass|adminpass|password|key)=[^&\s"]*`)
+
+// redact masks the credentials of the client and the other secrets given
+// in s.
+func (c *Client) redact(s string, secrets ...string) string {
+	s = apiKeyParamRe.ReplaceAllString(s, "$1=***")
+	creds, err := c.credentials()
+	if err == nil {
+		secrets = append(secrets[:len(secrets):len(secrets)], creds.ApiKey, creds.ApiPass)
+	}
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		s = strings.ReplaceAll(s, secret, "***")
+		s = strings.ReplaceAll(s, url.QueryEscape(secret), "***")
+	}
+	return s
+}
+
+// redactedError is an error with the credentials masked in its message.
+// It unwraps to a redacted copy of the wrapped error, so the secrets can
+// not be read back through errors.Unwrap or errors.As either.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError returns err with the credentials of the client and the other
+// secrets given masked in its whole chain. Errors not holding any secret
+// are returned as is, keeping sentinels like context.Canceled and net
+// errors reachable.
+func (c *Client) redactError(err error, secrets ...string) error {
+	if err == nil {
+		return nil
+	}
+	if ue, isUrlError := err.(*url.Error); isUrlError {
+		return &url.Error{Op: ue.Op, URL: c.redact(ue.URL, secrets...), Err: c.redactError(ue.Err, secrets...)}
+	}
+	msg := c.redact(err.Error(), secrets...)
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: c.redactError(errors.Unwrap(err), secrets...)}
+}
+
+// String describes the client with its secrets masked.
+func (c *Client) String() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	mask := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "***"
+	}
+	return fmt.Sprintf("Client{RestUrl: %q, Version: %d, ApiKey: %q, ApiUser: %q, ApiPass: %q}", c.RestUrl, c.Version, mask(c.ApiKey), c.ApiUser, mask(c.ApiPass))
+}
+
+func (c *Client) GoString() string {
+	return "&lmclient." + c.String()
 }
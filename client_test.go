@@ -1,13 +1,30 @@
 package lmclient
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // equals fails the test if exp is not equal to act.
@@ -41,7 +58,7 @@ func TestLoadMasterClient(t *testing.T) {
 	}))
 
 	defer server.Close()
-	client := Client{server.Client(), "bar", "foo", "baz", server.URL, 2}
+	client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: 2}
 	payload := struct {
 		CMD    string `json:"cmd" qs:"-"`
 		ApiKey string `json:"apikey" qs:"apikey"`
@@ -58,3 +75,205 @@ func TestLoadMasterClient(t *testing.T) {
 	equals(t, []byte("baz"), resp)
 
 }
+
+func TestClientCertificateAuth(t *testing.T) {
+	// Create a self-signed client certificate
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ok(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "lmclient"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	ok(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	ok(t, err)
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	ok(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	ok(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+
+	content, err := ioutil.ReadFile("test_data/listvs.xml")
+	ok(t, err)
+	// Start a local HTTPS server requiring a client certificate
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		equals(t, "/access/listvs", req.URL.String())
+		equals(t, 1, len(req.TLS.PeerCertificates))
+		equals(t, "lmclient", req.TLS.PeerCertificates[0].Subject.CommonName)
+		_, err := rw.Write(content)
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	client, err := NewClientWithCertFiles(certFile, keyFile, server.URL, 1)
+	ok(t, err)
+
+	vs, err := client.GetAllVs()
+	ok(t, err)
+	equals(t, "foo", vs[0].NickName)
+}
+
+func TestApiKeyNotInQuery(t *testing.T) {
+	testCases := []struct {
+		inquery bool
+		url     string
+		header  string
+	}{
+		{false, "/access/foo", "bar"},
+		{true, "/access/foo?apikey=bar", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("inquery_%t", tc.inquery), func(t *testing.T) {
+			// Start a local HTTP server
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// Test request parameters
+				equals(t, req.URL.String(), tc.url)
+				equals(t, req.Header.Get(ApiKeyHeader), tc.header)
+			}))
+
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: 1, ApiKeyInQuery: tc.inquery}
+
+			req, err := client.newRequest("foo", struct{}{})
+			ok(t, err)
+			_, err = client.doRequest(req)
+			ok(t, err)
+		})
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	// Start a local HTTP server echoing the request in its error
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusUnauthorized)
+		_, err := rw.Write([]byte("invalid key s3cr3t in " + req.URL.String()))
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+
+	defer server.Close()
+	client := Client{HttpClient: server.Client(), ApiKey: "s3cr3t", ApiUser: "foo", ApiPass: "p4ss", RestUrl: server.URL, Version: 1, ApiKeyInQuery: true}
+
+	_, err := client.GetAllVs()
+	equals(t, "status: 401, body: invalid key *** in /access/listvs?apikey=***", err.Error())
+
+	equals(t, fmt.Sprintf(`Client{RestUrl: %q, Version: 1, ApiKey: "***", ApiUser: "foo", ApiPass: "***"}`, server.URL), client.String())
+	equals(t, "&lmclient."+client.String(), fmt.Sprintf("%#v", &client))
+}
+
+func TestRedactionUnwrap(t *testing.T) {
+	// Start a local HTTP server closed before the request is sent
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	server.Close()
+	client := Client{HttpClient: server.Client(), ApiKey: "s3cr3t", RestUrl: server.URL, Version: 1, ApiKeyInQuery: true}
+
+	_, err := client.GetAllVs()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	var ue *url.Error
+	if !errors.As(err, &ue) {
+		t.Fatalf("expected a *url.Error in %v", err)
+	}
+	equals(t, false, strings.Contains(ue.URL, "s3cr3t"))
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		for _, s := range []string{e.Error(), fmt.Sprintf("%+v", e), fmt.Sprintf("%#v", e)} {
+			if strings.Contains(s, "s3cr3t") {
+				t.Fatalf("secret leaked in error chain: %s", s)
+			}
+		}
+	}
+}
+
+// countingReader counts the reads of the data of an upload.
+type countingReader struct {
+	reads int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	return 0, io.EOF
+}
+
+func TestNewUploadRequest(t *testing.T) {
+	client := Client{ApiKey: "bar", RestUrl: "http://loadmaster.invalid", Version: 2}
+
+	// No credentials are merged into the payload with a certificate
+	certClient := Client{Certificate: &tls.Certificate{}, RestUrl: "http://loadmaster.invalid", Version: 2}
+	_, err := certClient.newUploadRequest(context.Background(), "license", nil, strings.NewReader("blob"))
+	equals(t, "Payload of license is not a JSON object", err.Error())
+
+	// Built but not sent, the writer is never started
+	data := &countingReader{}
+	req, err := client.newUploadRequest(context.Background(), "license", struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{CMD: "license"}, data)
+	ok(t, err)
+	ok(t, req.Body.Close())
+	_, err = req.Body.Read(make([]byte, 1))
+	equals(t, io.ErrClosedPipe, err)
+	equals(t, 0, data.reads)
+
+	// Sent, the body embeds the data in the payload
+	req, err = client.newUploadRequest(context.Background(), "license", struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{CMD: "license"}, strings.NewReader("blob"))
+	ok(t, err)
+	body, err := ioutil.ReadAll(req.Body)
+	ok(t, err)
+	var payload map[string]string
+	ok(t, json.Unmarshal(body, &payload))
+	equals(t, "license", payload["cmd"])
+	equals(t, "YmxvYg==", payload["data"])
+}
+
+// newCommandServer starts a local HTTP server answering each command with
+// the test data file named in responses, in the format of apiversion. The
+// commands received are recorded in cmds.
+func newCommandServer(t *testing.T, apiversion int, responses map[string]string, cmds *[]string) *httptest.Server {
+	return httptest.NewServer(newCommandHandler(t, apiversion, responses, cmds))
+}
+
+func newCommandHandler(t *testing.T, apiversion int, responses map[string]string, cmds *[]string) http.HandlerFunc {
+	var mu sync.Mutex
+	return func(rw http.ResponseWriter, req *http.Request) {
+		var cmd string
+		ext := ".json"
+		if apiversion == 1 {
+			cmd = strings.TrimPrefix(req.URL.Path, "/access/")
+			ext = ".xml"
+		} else {
+			body, err := ioutil.ReadAll(req.Body)
+			ok(t, err)
+			var payload struct {
+				CMD string `json:"cmd"`
+			}
+			ok(t, json.Unmarshal(body, &payload))
+			cmd = payload.CMD
+		}
+		mu.Lock()
+		*cmds = append(*cmds, cmd)
+		mu.Unlock()
+
+		name, found := responses[cmd]
+		if !found {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		content, err := ioutil.ReadFile("test_data/" + name + ext)
+		ok(t, err)
+		_, err = rw.Write(content)
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}
+}
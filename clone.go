@@ -0,0 +1,259 @@
+package lmclient
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// CloneOptions adjust what CloneVs copies.
+type CloneOptions struct {
+	// NickName of the clone, the nickname of the source if empty.
+	NickName string
+	// RsAddress, if set, returns the address to use for a Real Server of
+	// the source, e.g. to point a staging clone at staging servers.
+	RsAddress func(rs Rs) string
+	// SkipDisabledRs leaves out Real Servers that are disabled on the
+	// source. Otherwise they are copied and disabled on the clone.
+	SkipDisabledRs bool
+}
+
+// CloneVs creates a copy of the Virtual Service with index srcIndex on
+// newAddress and newPort, with its rules, Real Servers and SubVSs. Only the
+// settings modelled by Vs are copied to the clone and its SubVSs, others
+// such as persistence, scheduling or SSL keep their defaults. opts may be
+// nil. If copying fails halfway the incomplete clone is left in place and
+// its index is part of the error.
+func (c *Client) CloneVs(srcIndex int, newAddress string, newPort string, opts *CloneOptions) (*Vs, error) {
+	if opts == nil {
+		opts = &CloneOptions{}
+	}
+
+	src, err := c.GetVs(srcIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := *src
+	spec.Address = newAddress
+	spec.Port = newPort
+	spec.VSPort = newPort
+	if opts.NickName != "" {
+		spec.NickName = opts.NickName
+	}
+	dst, err := c.CreateVs(&spec)
+	if err != nil {
+		return nil, err
+	}
+
+	spec.Index = dst.Index
+	if _, err := c.ModifyVs(&spec); err == nil {
+		err = c.cloneVsContents(src, dst.Index, opts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cloning Virtual Service %d left incomplete clone %d: %w", srcIndex, dst.Index, err)
+	}
+	return c.GetVs(dst.Index)
+}
+
+// cloneVsContents copies the rules, Real Servers and SubVSs of src to the
+// Virtual Service with index dstIndex.
+func (c *Client) cloneVsContents(src *Vs, dstIndex int, opts *CloneOptions) error {
+	for _, stage := range []struct {
+		cmd   string
+		rules []string
+	}{
+		{"addprerule", src.PreProcessRules},
+		{"addrequestrule", src.RequestRules},
+		{"addresponserule", src.ResponseRules},
+		{"addbodyrule", src.MatchBodyRules},
+	} {
+		for _, rule := range stage.rules {
+			if _, err := c.vsRuleCommand(stage.cmd, dstIndex, rule); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, rs := range src.Rs {
+		if !rs.Enable && opts.SkipDisabledRs {
+			continue
+		}
+		if err := c.cloneRs(rs, dstIndex, opts); err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range src.SubVS {
+		srcSub, err := c.GetVs(sub.VSIndex)
+		if err != nil {
+			return err
+		}
+		dstSub, err := c.CreateSubVs(dstIndex)
+		if err != nil {
+			return err
+		}
+		if _, err := c.modifySubVs(srcSub, dstSub.Index); err != nil {
+			return err
+		}
+		if err := c.cloneVsContents(srcSub, dstSub.Index, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// modifySubVs applies the settings of v to the SubVS with index subindex.
+// Unlike ModifyVs it sends no address or port, which a SubVS does not have,
+// and forces the layer only if v has one.
+func (c *Client) modifySubVs(v *Vs, subindex int) (*ApiResponse, error) {
+	cmd := "modvs"
+	payload := struct {
+		CMD        string `json:"cmd" qs:"-"`
+		Index      int    `json:"vs" qs:"vs"`
+		NickName   string `json:"NickName,omitempty" qs:"NickName,omitempty"`
+		Type       string `json:"VStype,omitempty" qs:"VSType,omitempty"`
+		Enable     string `json:"Enable" qs:"Enable"`
+		ForceL4    int    `json:"ForceL4,omitempty" qs:"forcel4,omitempty"`
+		ForceL7    int    `json:"ForceL7,omitempty" qs:"forcel7,omitempty"`
+		DefaultGW  string `json:"DefaultGW,omitempty" qs:"defaultgw,omitempty"`
+		CheckType  string `json:"CheckType,omitempty" qs:"checktype,omitempty"`
+		CheckUrl   string `json:"CheckUrl,omitempty" qs:"checkurl,omitempty"`
+		CheckCodes string `json:"CheckCodes,omitempty" qs:"checkcodes,omitempty"`
+		CheckPort  string `json:"CheckPort,omitempty" qs:"checkport,omitempty"`
+		*wafPayload
+		*espPayload
+		*limitsPayload
+	}{
+		CMD:           cmd,
+		Index:         subindex,
+		NickName:      v.NickName,
+		Type:          v.Type,
+		Enable:        formatBool(v.Enable),
+		DefaultGW:     v.DefaultGW,
+		CheckType:     v.CheckType,
+		CheckUrl:      v.CheckUrl,
+		CheckCodes:    v.CheckCodes,
+		CheckPort:     v.CheckPort,
+		wafPayload:    v.WAF.payload(),
+		espPayload:    v.ESP.payload(),
+		limitsPayload: v.Limits.payload(),
+	}
+	switch v.Layer {
+	case 4:
+		payload.ForceL4 = 1
+	case 7:
+		payload.ForceL7 = 1
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+func (c *Client) cloneRs(rs Rs, vsindex int, opts *CloneOptions) error {
+	spec := rs
+	spec.VSIndex = vsindex
+	if opts.RsAddress != nil {
+		spec.Addr = opts.RsAddress(rs)
+	}
+	created, err := c.CreateRs(&spec)
+	if err != nil {
+		return err
+	}
+
+	spec.RsIndex = created.RsIndex
+	if _, err := c.ModifyRs(&spec); err != nil {
+		return err
+	}
+	if !rs.Enable {
+		if _, err := c.disableRs(vsindex, created.RsIndex); err != nil {
+			return err
+		}
+	}
+	for _, rule := range rs.MatchRules {
+		if _, err := c.rsRuleCommand("addrsrule", vsindex, created.RsIndex, rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateSubVs adds a SubVS to the Virtual Service with index vsindex and
+// returns it.
+func (c *Client) CreateSubVs(vsindex int) (*Vs, error) {
+	parent, err := c.GetVs(vsindex)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[int]bool, len(parent.SubVS))
+	for _, sub := range parent.SubVS {
+		existing[sub.VSIndex] = true
+	}
+
+	defer c.InvalidateNameCache()
+	cmd := "modvs"
+	payload := struct {
+		CMD         string `json:"cmd" qs:"-"`
+		Index       int    `json:"vs" qs:"vs"`
+		CreateSubVs string `json:"createsubvs" qs:"createsubvs"`
+	}{
+		CMD:   cmd,
+		Index: vsindex,
+	}
+
+	var vs Vs
+	if err := c.sendCommand(cmd, payload, &vs); err != nil {
+		return nil, err
+	}
+	for _, sub := range vs.SubVS {
+		if !existing[sub.VSIndex] {
+			return c.GetVs(sub.VSIndex)
+		}
+	}
+	return nil, fmt.Errorf("New SubVS of Virtual Service %d not found", vsindex)
+}
+
+func (c *Client) disableRs(vsindex int, rsindex int) (*ApiResponse, error) {
+	cmd := "modrs"
+	payload := struct {
+		CMD     string `json:"cmd" qs:"-"`
+		VSIndex int    `json:"vs" qs:"vs"`
+		Rsi     string `json:"rs" qs:"rs"`
+		Enable  string `json:"enable" qs:"enable"`
+	}{
+		CMD:     cmd,
+		VSIndex: vsindex,
+		Rsi:     "!" + strconv.Itoa(rsindex),
+		Enable:  "N",
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+func (c *Client) vsRuleCommand(cmd string, vsindex int, rule string) (*ApiResponse, error) {
+	payload := struct {
+		CMD   string `json:"cmd" qs:"-"`
+		Index int    `json:"vs" qs:"vs"`
+		Rule  string `json:"rule" qs:"rule"`
+	}{
+		CMD:   cmd,
+		Index: vsindex,
+		Rule:  rule,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+func (c *Client) rsRuleCommand(cmd string, vsindex int, rsindex int, rule string) (*ApiResponse, error) {
+	payload := struct {
+		CMD     string `json:"cmd" qs:"-"`
+		VSIndex int    `json:"vs" qs:"vs"`
+		Rsi     string `json:"rs" qs:"rs"`
+		Rule    string `json:"rule" qs:"rule"`
+	}{
+		CMD:     cmd,
+		VSIndex: vsindex,
+		Rsi:     "!" + strconv.Itoa(rsindex),
+		Rule:    rule,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
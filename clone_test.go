@@ -0,0 +1,130 @@
+package lmclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newCloneServer answers showvs for index 5 with a Virtual Service having
+// rules, Real Servers and a SubVS, and every other index with a plain one.
+// The commands received are recorded with the Real Server address of addrs
+// and the rule parameter, the parameters of modvs are recorded in mods.
+func newCloneServer(t *testing.T, apiversion int, cmds *[]string, mods *[]map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var cmd string
+		params := map[string]string{}
+		ext := ".json"
+		if apiversion == 1 {
+			cmd = strings.TrimPrefix(req.URL.Path, "/access/")
+			for k, v := range req.URL.Query() {
+				params[k] = v[0]
+			}
+			ext = ".xml"
+		} else {
+			body, err := ioutil.ReadAll(req.Body)
+			ok(t, err)
+			var payload map[string]interface{}
+			ok(t, json.Unmarshal(body, &payload))
+			for k, v := range payload {
+				params[k] = fmt.Sprint(v)
+			}
+			cmd = params["cmd"]
+		}
+		rec := []string{cmd}
+		if cmd == "addrs" {
+			rec = append(rec, params["rs"])
+		}
+		if params["rule"] != "" {
+			rec = append(rec, params["rule"])
+		}
+		*cmds = append(*cmds, strings.Join(rec, " "))
+		if cmd == "modvs" {
+			*mods = append(*mods, params)
+		}
+
+		name := "ok"
+		switch cmd {
+		case "showvs":
+			name = "showvs"
+			if params["vs"] == "5" {
+				name = "showvsclone"
+			}
+		case "modvs":
+			name = "modvs"
+			if _, found := params["createsubvs"]; found {
+				name = "showvssub"
+			}
+		case "addvs", "addrs":
+			name = cmd
+		}
+		content, err := ioutil.ReadFile("test_data/" + name + ext)
+		ok(t, err)
+		_, err = rw.Write(content)
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+}
+
+func TestCloneVs(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			var mods []map[string]string
+			server := newCloneServer(t, apiversion, &cmds, &mods)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			_, err := client.CloneVs(5, "192.168.2.239", "80", &CloneOptions{
+				NickName: "foo-staging",
+				RsAddress: func(rs Rs) string {
+					return strings.Replace(rs.Addr, "10.10.", "10.20.", 1)
+				},
+			})
+			ok(t, err)
+			equals(t, []string{
+				"showvs", "addvs", "showvs", "modvs", "addrequestrule addxff",
+				"addrs 10.20.10.10", "modrs", "addrsrule api",
+				"addrs 10.20.10.11", "modrs", "modrs",
+				"showvs", "showvs", "modvs", "showvs", "modvs",
+				"showvs",
+			}, cmds)
+
+			// The clone is moved, the SubVS has no address or port
+			equals(t, 3, len(mods))
+			equals(t, "192.168.2.239", mods[0]["vsaddress"])
+			equals(t, "80", mods[0]["port"])
+			_, found := mods[1]["createsubvs"]
+			equals(t, true, found)
+			sub := mods[2]
+			_, found = sub["vsaddress"]
+			equals(t, false, found)
+			_, found = sub["port"]
+			equals(t, false, found)
+			_, found = sub["vsport"]
+			equals(t, false, found)
+			if apiversion == 1 {
+				equals(t, "1", sub["forcel7"])
+				equals(t, "http", sub["checktype"])
+			} else {
+				equals(t, "1", sub["ForceL7"])
+				equals(t, "http", sub["CheckType"])
+			}
+
+			cmds = nil
+			_, err = client.CloneVs(5, "192.168.2.239", "80", &CloneOptions{SkipDisabledRs: true})
+			ok(t, err)
+			equals(t, []string{
+				"showvs", "addvs", "showvs", "modvs", "addrequestrule addxff",
+				"addrs 10.10.10.10", "modrs", "addrsrule api",
+				"showvs", "showvs", "modvs", "showvs", "modvs",
+				"showvs",
+			}, cmds)
+		})
+	}
+}
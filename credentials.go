@@ -0,0 +1,261 @@
+package lmclient
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type Credentials struct {
+	ApiKey  string
+	ApiUser string
+	ApiPass string
+}
+
+func (c Credentials) empty() bool {
+	return c.ApiKey == "" && (c.ApiUser == "" || c.ApiPass == "")
+}
+
+// CredentialsProvider supplies the credentials for a request. It is asked
+// before every request, so providers can pick up rotated secrets.
+type CredentialsProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// ApiKeySetter is implemented by providers that can store a new API key,
+// which RotateApiKey requires of the provider of a client.
+type ApiKeySetter interface {
+	SetApiKey(apiKey string) error
+}
+
+// StaticProvider always returns the same credentials.
+type StaticProvider Credentials
+
+func (p StaticProvider) Credentials() (Credentials, error) {
+	return Credentials(p), nil
+}
+
+// EnvProvider reads the credentials from environment variables, by default
+// LOADMASTER_API_KEY, LOADMASTER_API_USER and LOADMASTER_API_PASS.
+type EnvProvider struct {
+	KeyVar  string
+	UserVar string
+	PassVar string
+}
+
+func NewEnvProvider() *EnvProvider {
+	return &EnvProvider{
+		KeyVar:  "LOADMASTER_API_KEY",
+		UserVar: "LOADMASTER_API_USER",
+		PassVar: "LOADMASTER_API_PASS",
+	}
+}
+
+func (p *EnvProvider) Credentials() (Credentials, error) {
+	return Credentials{
+		ApiKey:  os.Getenv(p.KeyVar),
+		ApiUser: os.Getenv(p.UserVar),
+		ApiPass: os.Getenv(p.PassVar),
+	}, nil
+}
+
+// fileCache reparses a file only when its content changes. The content is
+// compared by hash, as a rewrite within the timestamp resolution of the
+// file system may keep both modification time and size.
+type fileCache struct {
+	path string
+
+	mu    sync.Mutex
+	sum   [sha256.Size]byte
+	read  bool
+	creds Credentials
+	parse func(r io.Reader) (Credentials, error)
+}
+
+func (fc *fileCache) credentials() (Credentials, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	content, err := ioutil.ReadFile(fc.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	sum := sha256.Sum256(content)
+	if fc.read && sum == fc.sum {
+		return fc.creds, nil
+	}
+
+	creds, err := fc.parse(bytes.NewReader(content))
+	if err != nil {
+		return Credentials{}, fmt.Errorf("%s: %w", fc.path, err)
+	}
+	fc.creds, fc.sum, fc.read = creds, sum, true
+	return creds, nil
+}
+
+// FileProvider reads the credentials from a file of key=value lines with
+// the keys apikey, apiuser and apipass. Empty lines and lines starting with
+// # are ignored. The file is read for every request and parsed again
+// whenever its content changes.
+type FileProvider struct {
+	cache fileCache
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{
+		cache: fileCache{path: path, parse: parseCredentialsFile},
+	}
+}
+
+func (p *FileProvider) Credentials() (Credentials, error) {
+	return p.cache.credentials()
+}
+
+func parseCredentialsFile(r io.Reader) (Credentials, error) {
+	var creds Credentials
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return Credentials{}, fmt.Errorf("Invalid line: %s", line)
+		}
+		switch strings.TrimSpace(key) {
+		case "apikey":
+			creds.ApiKey = strings.TrimSpace(value)
+		case "apiuser":
+			creds.ApiUser = strings.TrimSpace(value)
+		case "apipass":
+			creds.ApiPass = strings.TrimSpace(value)
+		default:
+			return Credentials{}, fmt.Errorf("Unknown key: %s", key)
+		}
+	}
+	return creds, scanner.Err()
+}
+
+// NetrcProvider reads the credentials for a host from a .netrc style file,
+// taking login and password as user and password and account as API key.
+// The file is read for every request and parsed again whenever its content
+// changes.
+type NetrcProvider struct {
+	cache fileCache
+}
+
+// NewNetrcProvider returns a provider for the host of restUrl. An empty path
+// means ~/.netrc.
+func NewNetrcProvider(path string, restUrl string) (*NetrcProvider, error) {
+	u, err := url.Parse(restUrl)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+	host := u.Hostname()
+	return &NetrcProvider{
+		cache: fileCache{path: path, parse: func(r io.Reader) (Credentials, error) {
+			return parseNetrc(r, host)
+		}},
+	}, nil
+}
+
+func (p *NetrcProvider) Credentials() (Credentials, error) {
+	return p.cache.credentials()
+}
+
+func parseNetrc(r io.Reader, host string) (Credentials, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	var tokens []string
+	for scanner.Scan() {
+		tokens = append(tokens, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return Credentials{}, err
+	}
+
+	var creds, dflt Credentials
+	var cur *Credentials
+	found, hasDefault := false, false
+	for i := 0; i < len(tokens); i++ {
+		next := func() string {
+			if i+1 < len(tokens) {
+				i++
+				return tokens[i]
+			}
+			return ""
+		}
+		switch tokens[i] {
+		case "machine":
+			cur = nil
+			if next() == host && !found {
+				found = true
+				cur = &creds
+			}
+		case "default":
+			cur = nil
+			if !hasDefault {
+				hasDefault = true
+				cur = &dflt
+			}
+		case "login":
+			if v := next(); cur != nil {
+				cur.ApiUser = v
+			}
+		case "password":
+			if v := next(); cur != nil {
+				cur.ApiPass = v
+			}
+		case "account":
+			if v := next(); cur != nil {
+				cur.ApiKey = v
+			}
+		}
+	}
+	if found {
+		return creds, nil
+	}
+	if hasDefault {
+		return dflt, nil
+	}
+	return Credentials{}, fmt.Errorf("No entry for %s", host)
+}
+
+// ChainProvider asks each provider in turn and returns the first complete
+// credentials found.
+type ChainProvider []CredentialsProvider
+
+func (p ChainProvider) Credentials() (Credentials, error) {
+	var errs []string
+	for _, provider := range p {
+		creds, err := provider.Credentials()
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if !creds.empty() {
+			return creds, nil
+		}
+	}
+	if len(errs) > 0 {
+		return Credentials{}, fmt.Errorf("No credentials found: %s", strings.Join(errs, "; "))
+	}
+	return Credentials{}, errors.New("No credentials found")
+}
@@ -0,0 +1,100 @@
+package lmclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	ok(t, ioutil.WriteFile(path, []byte("# LoadMaster\napikey = first\n"), 0600))
+
+	content, err := ioutil.ReadFile("test_data/listvs.xml")
+	ok(t, err)
+	var got string
+	// Start a local HTTP server
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		got = req.Header.Get(ApiKeyHeader)
+		_, err := rw.Write(content)
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClientWithProvider(NewFileProvider(path), server.URL, 1)
+	client.HttpClient = server.Client()
+
+	_, err = client.GetAllVs()
+	ok(t, err)
+	equals(t, "first", got)
+
+	ok(t, ioutil.WriteFile(path, []byte("apikey=second\n"), 0600))
+	// Make sure the change is noticed on file systems with coarse timestamps
+	later := time.Now().Add(time.Minute)
+	ok(t, os.Chtimes(path, later, later))
+
+	_, err = client.GetAllVs()
+	ok(t, err)
+	equals(t, "second", got)
+
+	// A rewrite keeping modification time and size is noticed as well
+	fi, err := os.Stat(path)
+	ok(t, err)
+	ok(t, ioutil.WriteFile(path, []byte("apikey=third!\n"), 0600))
+	ok(t, os.Chtimes(path, fi.ModTime(), fi.ModTime()))
+
+	_, err = client.GetAllVs()
+	ok(t, err)
+	equals(t, "third!", got)
+}
+
+func TestNetrcProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "netrc")
+	ok(t, ioutil.WriteFile(path, []byte(`machine other.example.com login nobody password nothing
+machine lm1.example.com
+  login admin
+  password secret
+  account key1
+default login guest password guest
+`), 0600))
+
+	p, err := NewNetrcProvider(path, "https://lm1.example.com:8443/")
+	ok(t, err)
+	creds, err := p.Credentials()
+	ok(t, err)
+	equals(t, Credentials{ApiKey: "key1", ApiUser: "admin", ApiPass: "secret"}, creds)
+
+	p, err = NewNetrcProvider(path, "https://lm2.example.com/")
+	ok(t, err)
+	creds, err = p.Credentials()
+	ok(t, err)
+	equals(t, Credentials{ApiUser: "guest", ApiPass: "guest"}, creds)
+}
+
+func TestChainProvider(t *testing.T) {
+	os.Setenv("LMCLIENT_TEST_KEY", "")
+	chain := ChainProvider{
+		&EnvProvider{KeyVar: "LMCLIENT_TEST_KEY"},
+		NewFileProvider(filepath.Join(t.TempDir(), "missing")),
+		StaticProvider{ApiKey: "static"},
+	}
+	creds, err := chain.Credentials()
+	ok(t, err)
+	equals(t, "static", creds.ApiKey)
+
+	os.Setenv("LMCLIENT_TEST_KEY", "fromenv")
+	defer os.Unsetenv("LMCLIENT_TEST_KEY")
+	creds, err = chain.Credentials()
+	ok(t, err)
+	equals(t, "fromenv", creds.ApiKey)
+
+	_, err = ChainProvider{}.Credentials()
+	equals(t, "No credentials found", err.Error())
+}
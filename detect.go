@@ -0,0 +1,123 @@
+package lmclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ApplianceInfo struct {
+	Model           string
+	FirmwareVersion string
+	Serial          string
+	Uptime          time.Duration
+	ApiVersion      int
+}
+
+// NewClientDetect is like NewClient but asks the LoadMaster which API
+// version it supports instead of taking it as argument.
+func NewClientDetect(apiKey string, apiUser string, apiPass string, restUrl string) (*Client, error) {
+	c := NewClient(apiKey, apiUser, apiPass, restUrl, 2)
+	if _, err := c.DetectVersion(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DetectVersion probes the LoadMaster for API version 2, falling back to
+// version 1 if it does not support it, and sets Version accordingly. Other
+// errors, like rejected credentials, are returned without falling back. The
+// appliance information read while probing is cached for ApplianceInfo. It
+// must not be called while other requests of the client are in flight.
+func (c *Client) DetectVersion() (int, error) {
+	orig := c.Version
+	var errs []string
+	for _, version := range []int{2, 1} {
+		c.setVersion(version)
+		info, err := c.fetchApplianceInfo()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("version %d: %v", version, err))
+			if version == 2 && v2Unsupported(err) {
+				continue
+			}
+			break
+		}
+		c.mu.Lock()
+		c.info = info
+		c.mu.Unlock()
+		return version, nil
+	}
+	c.setVersion(orig)
+	return 0, fmt.Errorf("Could not detect API version: %s", strings.Join(errs, "; "))
+}
+
+func (c *Client) setVersion(version int) {
+	c.mu.Lock()
+	c.Version = version
+	c.mu.Unlock()
+}
+
+// v2Unsupported reports whether err is how a LoadMaster without API version
+// 2 answers it: not found or an unknown command.
+func v2Unsupported(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "status: 404,") || strings.HasPrefix(msg, "Code: 404 ") ||
+		strings.Contains(strings.ToLower(msg), "unknown command")
+}
+
+// ApplianceInfo returns model, firmware version, serial number and uptime
+// of the LoadMaster. The information is read once and cached, apart from
+// the uptime which is advanced from the time it was read.
+func (c *Client) ApplianceInfo() (*ApplianceInfo, error) {
+	c.mu.RLock()
+	info := c.info
+	c.mu.RUnlock()
+
+	if info == nil {
+		var err error
+		info, err = c.fetchApplianceInfo()
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.info = info
+		c.mu.Unlock()
+	}
+
+	res := *info
+	res.Uptime += time.Since(info.fetched)
+	return &res.ApplianceInfo, nil
+}
+
+type cachedApplianceInfo struct {
+	ApplianceInfo
+	fetched time.Time
+}
+
+func (c *Client) fetchApplianceInfo() (*cachedApplianceInfo, error) {
+	p, err := c.getParams([]string{"version", "serialnumber", "model", "uptime"})
+	if err != nil {
+		return nil, err
+	}
+	if p["version"] == "" {
+		return nil, fmt.Errorf("No firmware version in response")
+	}
+	uptime := 0
+	if p["uptime"] != "" {
+		uptime, err = strconv.Atoi(strings.TrimSpace(p["uptime"]))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &cachedApplianceInfo{
+		ApplianceInfo: ApplianceInfo{
+			Model:           p["model"],
+			FirmwareVersion: p["version"],
+			Serial:          p["serialnumber"],
+			Uptime:          time.Duration(uptime) * time.Second,
+			ApiVersion:      c.Version,
+		},
+		fetched: time.Now(),
+	}, nil
+}
@@ -0,0 +1,89 @@
+package lmclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDetectVersion(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			params := map[string]string{
+				"version":      "7.2.54.0.20870.RELEASE",
+				"serialnumber": "1234567",
+				"model":        "VLM-200",
+				"uptime":       "3600",
+			}
+			var set []string
+			handler := newParamHandler(t, apiversion, params, &set)
+			// Start a local HTTP server only serving one API version
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if (req.URL.Path == "/accessv2") != (apiversion == 2) {
+					rw.WriteHeader(http.StatusNotFound)
+					return
+				}
+				handler(rw, req)
+			}))
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL}
+
+			version, err := client.DetectVersion()
+			ok(t, err)
+			equals(t, apiversion, version)
+			equals(t, apiversion, client.Version)
+
+			// The cached information is used without asking again
+			server.Close()
+			info, err := client.ApplianceInfo()
+			ok(t, err)
+			equals(t, "VLM-200", info.Model)
+			equals(t, "7.2.54.0.20870.RELEASE", info.FirmwareVersion)
+			equals(t, "1234567", info.Serial)
+			equals(t, true, info.Uptime >= time.Hour)
+			equals(t, apiversion, info.ApiVersion)
+		})
+	}
+}
+
+func TestDetectVersionNoFallback(t *testing.T) {
+	var requests []string
+	// Start a local HTTP server rejecting the credentials
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.URL.Path)
+		rw.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: 2}
+
+	_, err := client.DetectVersion()
+	equals(t, "Could not detect API version: version 2: status: 401, body: ", err.Error())
+	equals(t, []string{"/accessv2"}, requests)
+	equals(t, 2, client.Version)
+}
+
+func TestDetectVersionUnknownCommand(t *testing.T) {
+	params := map[string]string{"version": "7.1.35.0.14900.RELEASE"}
+	var set []string
+	handler := newParamHandler(t, 1, params, &set)
+	// Start a local HTTP server not knowing the API version 2 commands
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/accessv2" {
+			rw.WriteHeader(http.StatusBadRequest)
+			_, err := rw.Write([]byte(`{ "code": 400, "message": "Unknown command", "status": "fail" }`))
+			if err != nil {
+				fmt.Printf("Write failed: %v", err)
+			}
+			return
+		}
+		handler(rw, req)
+	}))
+	defer server.Close()
+	client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL}
+
+	version, err := client.DetectVersion()
+	ok(t, err)
+	equals(t, 1, version)
+}
@@ -0,0 +1,236 @@
+package lmclient
+
+import (
+	"errors"
+	"reflect"
+)
+
+// EnsureVs makes sure a Virtual Service matching spec exists. An existing
+// Virtual Service is looked up by spec.NickName, or by address, port and
+// protocol if no nickname is given. It is created if missing, along with
+// the WAF and ESP of spec, otherwise only the fields set in spec that
+// differ are modified. Empty strings, a
+// zero Layer and a nil WAF, ESP or Limits in spec leave the existing value
+// alone, Enable is always applied. A differing WAF, ESP or Limits is sent
+// as a whole. The returned bool reports whether anything changed.
+func (c *Client) EnsureVs(spec *Vs) (*Vs, bool, error) {
+	current, err := c.findVs(spec)
+	if err != nil {
+		return nil, false, err
+	}
+	if current == nil {
+		vs, err := c.CreateVs(spec)
+		if err != nil {
+			return nil, false, err
+		}
+		if spec.WAF == nil && spec.ESP == nil {
+			return vs, true, nil
+		}
+		// addvs takes no WAF or ESP settings, they are applied afterwards
+		cmd := "modvs"
+		payload := struct {
+			CMD   string `json:"cmd" qs:"-"`
+			Index int    `json:"vs" qs:"vs"`
+			*wafPayload
+			*espPayload
+		}{
+			CMD:        cmd,
+			Index:      vs.Index,
+			wafPayload: spec.WAF.payload(),
+			espPayload: spec.ESP.payload(),
+		}
+		var modified Vs
+		if err := c.sendCommand(cmd, payload, &modified); err != nil {
+			return nil, true, err
+		}
+		return &modified, true, nil
+	}
+
+	cmd := "modvs"
+	payload := struct {
+		CMD        string `json:"cmd" qs:"-"`
+		Index      int    `json:"vs" qs:"vs"`
+		Address    string `json:"vsaddress,omitempty" qs:"vsaddress,omitempty"`
+		Port       string `json:"port,omitempty" qs:"port,omitempty"`
+		NickName   string `json:"NickName,omitempty" qs:"NickName,omitempty"`
+		Type       string `json:"VStype,omitempty" qs:"VSType,omitempty"`
+		Protocol   string `json:"prot,omitempty" qs:"prot,omitempty"`
+		Enable     string `json:"Enable,omitempty" qs:"Enable,omitempty"`
+		ForceL4    int    `json:"ForceL4,omitempty" qs:"forcel4,omitempty"`
+		ForceL7    int    `json:"ForceL7,omitempty" qs:"forcel7,omitempty"`
+		DefaultGW  string `json:"DefaultGW,omitempty" qs:"defaultgw,omitempty"`
+		CheckType  string `json:"CheckType,omitempty" qs:"checktype,omitempty"`
+		CheckUrl   string `json:"CheckUrl,omitempty" qs:"checkurl,omitempty"`
+		CheckCodes string `json:"CheckCodes,omitempty" qs:"checkcodes,omitempty"`
+		CheckPort  string `json:"CheckPort,omitempty" qs:"checkport,omitempty"`
+		*wafPayload
+		*espPayload
+		*limitsPayload
+	}{
+		CMD:   cmd,
+		Index: current.Index,
+	}
+	changed := false
+	setString := func(field *string, have string, want string) {
+		if want != "" && have != want {
+			*field = want
+			changed = true
+		}
+	}
+	if spec.Address != "" && !sameAddress(current.Address, spec.Address) {
+		payload.Address = spec.Address
+		changed = true
+	}
+	setString(&payload.Port, current.VSPort, spec.Port)
+	setString(&payload.NickName, current.NickName, spec.NickName)
+	setString(&payload.Type, current.Type, spec.Type)
+	setString(&payload.Protocol, current.Protocol, spec.Protocol)
+	setString(&payload.DefaultGW, current.DefaultGW, spec.DefaultGW)
+	setString(&payload.CheckType, current.CheckType, spec.CheckType)
+	setString(&payload.CheckUrl, current.CheckUrl, spec.CheckUrl)
+	setString(&payload.CheckCodes, current.CheckCodes, spec.CheckCodes)
+	setString(&payload.CheckPort, current.CheckPort, spec.CheckPort)
+	if current.Enable != spec.Enable {
+		payload.Enable = formatBool(spec.Enable)
+		changed = true
+	}
+	if spec.Layer != 0 && current.Layer != spec.Layer {
+		if spec.Layer == 4 {
+			payload.ForceL4 = 1
+		} else {
+			payload.ForceL7 = 1
+		}
+		changed = true
+	}
+	if spec.WAF != nil && !reflect.DeepEqual(current.WAF, spec.WAF) {
+		payload.wafPayload = spec.WAF.payload()
+		changed = true
+	}
+	if spec.ESP != nil && !reflect.DeepEqual(current.ESP, spec.ESP) {
+		payload.espPayload = spec.ESP.payload()
+		changed = true
+	}
+	if spec.Limits != nil && !reflect.DeepEqual(current.Limits, spec.Limits) {
+		payload.limitsPayload = spec.Limits.payload()
+		changed = true
+	}
+
+	if !changed {
+		return current, false, nil
+	}
+
+	defer c.InvalidateNameCache()
+	var vs Vs
+	if err := c.sendCommand(cmd, payload, &vs); err != nil {
+		return nil, false, err
+	}
+	return &vs, true, nil
+}
+
+// findVs returns the Virtual Service matching spec as described for
+// EnsureVs, or nil if there is none. Several Virtual Services with the
+// nickname, or the address, port and protocol of spec, are reported with an
+// *AmbiguousError.
+func (c *Client) findVs(spec *Vs) (*Vs, error) {
+	vss, err := c.GetAllVs()
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.NickName != "" {
+		listed, err := vsByName(vss, spec.NickName)
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return c.GetVs(listed.Index)
+	}
+
+	if spec.Address == "" {
+		return nil, errors.New("Virtual Service spec needs a nickname or an address")
+	}
+	filter := VsFilter{Address: spec.Address, Port: spec.Port, Protocol: spec.Protocol}
+	var indexes []int
+	for i := range vss {
+		if filter.match(&vss[i]) {
+			indexes = append(indexes, vss[i].Index)
+		}
+	}
+	switch len(indexes) {
+	case 0:
+		return nil, nil
+	case 1:
+		return c.GetVs(indexes[0])
+	}
+	name := spec.Address
+	if spec.Port != "" {
+		name += ":" + spec.Port
+	}
+	if spec.Protocol != "" {
+		name += "/" + spec.Protocol
+	}
+	return nil, &AmbiguousError{Name: name, Indexes: indexes}
+}
+
+// EnsureRs makes sure the Virtual Service with index vsindex has a Real
+// Server with the address and port of spec. It is created if missing,
+// otherwise the Weight, Forward, Limit and RateLimit set in spec that
+// differ are modified. The returned bool reports whether anything changed.
+func (c *Client) EnsureRs(vsindex int, spec *Rs) (*Rs, bool, error) {
+	vs, err := c.GetVs(vsindex)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var current *Rs
+	for i := range vs.Rs {
+		if vs.Rs[i].Addr == spec.Addr && vs.Rs[i].Port == spec.Port {
+			current = &vs.Rs[i]
+			break
+		}
+	}
+
+	if current == nil {
+		r := *spec
+		r.VSIndex = vsindex
+		rs, err := c.CreateRs(&r)
+		if err != nil {
+			return nil, false, err
+		}
+		return rs, true, nil
+	}
+
+	mod := Rs{VSIndex: vsindex, RsIndex: current.RsIndex}
+	changed := false
+	if spec.Weight != 0 && spec.Weight != current.Weight {
+		mod.Weight = spec.Weight
+		changed = true
+	}
+	if spec.Forward != "" && spec.Forward != current.Forward {
+		mod.Forward = spec.Forward
+		changed = true
+	}
+	if spec.Limit != 0 && spec.Limit != current.Limit {
+		mod.Limit = spec.Limit
+		changed = true
+	}
+	if spec.RateLimit != 0 && spec.RateLimit != current.RateLimit {
+		mod.RateLimit = spec.RateLimit
+		changed = true
+	}
+
+	if !changed {
+		return current, false, nil
+	}
+
+	if _, err := c.ModifyRs(&mod); err != nil {
+		return nil, false, err
+	}
+	rs, err := c.GetRs(current.RsIndex, vsindex)
+	if err != nil {
+		return nil, false, err
+	}
+	return rs, true, nil
+}
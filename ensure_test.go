@@ -0,0 +1,223 @@
+package lmclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEnsureVs(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{
+				"listvs": "listvs",
+				"showvs": "showvs",
+				"modvs":  "modvs",
+				"addvs":  "addvs",
+			}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			// Matching spec, nothing to do
+			vs, changed, err := client.EnsureVs(&Vs{NickName: "foo", Protocol: "tcp", Enable: true, Layer: 7, CheckType: "http"})
+			ok(t, err)
+			equals(t, false, changed)
+			equals(t, 1, vs.Index)
+			equals(t, []string{"listvs", "showvs"}, cmds)
+
+			// Differing health check
+			cmds = nil
+			_, changed, err = client.EnsureVs(&Vs{NickName: "foo", Enable: true, CheckType: "https"})
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, []string{"listvs", "showvs", "modvs"}, cmds)
+
+			// Unknown nickname
+			cmds = nil
+			_, changed, err = client.EnsureVs(&Vs{NickName: "bar", Address: "192.168.1.235", Port: "6443", Protocol: "tcp", Enable: true})
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, []string{"listvs", "addvs", "showvs"}, cmds)
+		})
+	}
+}
+
+func TestEnsureVsCreateSections(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			sent := map[string]map[string]string{}
+			handler := newCommandHandler(t, apiversion, map[string]string{
+				"listvs": "listvs",
+				"showvs": "showvs",
+				"modvs":  "modvs",
+				"addvs":  "addvs",
+			}, &cmds)
+			// Start a local HTTP server recording the parameters of each command
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				params := map[string]string{}
+				var cmd string
+				if apiversion == 1 {
+					cmd = strings.TrimPrefix(req.URL.Path, "/access/")
+					for k, v := range req.URL.Query() {
+						params[k] = v[0]
+					}
+				} else {
+					body, err := ioutil.ReadAll(req.Body)
+					ok(t, err)
+					var payload map[string]interface{}
+					ok(t, json.Unmarshal(body, &payload))
+					for k, v := range payload {
+						params[k] = fmt.Sprint(v)
+					}
+					cmd = params["cmd"]
+					req.Body = ioutil.NopCloser(bytes.NewReader(body))
+				}
+				sent[cmd] = params
+				handler(rw, req)
+			}))
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			_, changed, err := client.EnsureVs(&Vs{
+				NickName: "new", Address: "10.0.0.1", Port: "443", Protocol: "tcp",
+				WAF: &VsWAF{InterceptMode: WAFModeOwasp},
+				ESP: &VsESP{EspEnabled: true, Domain: "example.com"},
+			})
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, []string{"listvs", "addvs", "showvs", "modvs"}, cmds)
+			equals(t, "", sent["addvs"]["InterceptMode"])
+			equals(t, "2", sent["modvs"]["InterceptMode"])
+			equals(t, "Y", sent["modvs"]["EspEnabled"])
+			equals(t, "example.com", sent["modvs"]["Domain"])
+		})
+	}
+}
+
+func TestEnsureVsChangedFields(t *testing.T) {
+	var cmds []string
+	var modvs map[string]interface{}
+	handler := newCommandHandler(t, 2, map[string]string{
+		"listvs": "listvs",
+		"showvs": "showvs",
+		"modvs":  "modvs",
+	}, &cmds)
+	// Start a local HTTP server recording the modvs payload
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		ok(t, err)
+		var payload map[string]interface{}
+		ok(t, json.Unmarshal(body, &payload))
+		if payload["cmd"] == "modvs" {
+			modvs = payload
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		handler(rw, req)
+	}))
+	defer server.Close()
+	client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: 2}
+
+	// Looked up by address, port and protocol
+	_, changed, err := client.EnsureVs(&Vs{Address: "192.168.1.239", Port: "80", Protocol: "tcp", Enable: true, CheckType: "https"})
+	ok(t, err)
+	equals(t, true, changed)
+	equals(t, []string{"listvs", "showvs", "modvs"}, cmds)
+	delete(modvs, "apikey")
+	equals(t, map[string]interface{}{"cmd": "modvs", "vs": float64(1), "CheckType": "https"}, modvs)
+}
+
+func TestEnsureVsAmbiguous(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{"listvs": "listvsdup"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			_, changed, err := client.EnsureVs(&Vs{NickName: "foo", Enable: true, CheckType: "https"})
+			equals(t, true, errors.Is(err, ErrAmbiguous))
+			equals(t, false, changed)
+			equals(t, []string{"listvs"}, cmds)
+		})
+	}
+}
+
+func TestEnsureVsByAddress(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{
+				"listvs": "listvssameaddr",
+				"showvs": "showvs",
+				"modvs":  "modvs",
+			}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			// Neither nickname nor address
+			_, changed, err := client.EnsureVs(&Vs{Port: "80", Enable: true})
+			equals(t, true, err != nil)
+			equals(t, false, changed)
+			equals(t, []string{"listvs"}, cmds)
+
+			// Address and port shared by two Virtual Services
+			cmds = nil
+			_, changed, err = client.EnsureVs(&Vs{Address: "192.168.1.239", Port: "80", Enable: true})
+			var ambiguous *AmbiguousError
+			equals(t, true, errors.As(err, &ambiguous))
+			equals(t, []int{1, 2}, ambiguous.Indexes)
+			equals(t, false, changed)
+			equals(t, []string{"listvs"}, cmds)
+
+			// Single match
+			cmds = nil
+			_, changed, err = client.EnsureVs(&Vs{Address: "192.168.1.241", Port: "443", Protocol: "tcp", Enable: true})
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, []string{"listvs", "showvs", "modvs"}, cmds)
+		})
+	}
+}
+
+func TestEnsureRs(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{
+				"showvs": "showvsrs",
+				"showrs": "showrs",
+				"modrs":  "modrs",
+				"addrs":  "addrs",
+			}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			rs, changed, err := client.EnsureRs(1, &Rs{Addr: "10.10.10.10", Port: 8080, Weight: 1000})
+			ok(t, err)
+			equals(t, false, changed)
+			equals(t, 1, rs.RsIndex)
+			equals(t, []string{"showvs"}, cmds)
+
+			cmds = nil
+			_, changed, err = client.EnsureRs(1, &Rs{Addr: "10.10.10.10", Port: 8080, Weight: 500})
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, []string{"showvs", "modrs", "showrs"}, cmds)
+
+			cmds = nil
+			rs, changed, err = client.EnsureRs(1, &Rs{Addr: "10.10.10.10", Port: 9090})
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, 1, rs.VSIndex)
+			equals(t, []string{"showvs", "addrs"}, cmds)
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package lmclient
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Client authentication modes of an ESP enabled Virtual Service, set in
+// VsESP.InputAuthMode.
+const (
+	ESPInputNone       = 0
+	ESPInputBasic      = 1
+	ESPInputForm       = 2
+	ESPInputClientCert = 3
+	ESPInputNTLM       = 4
+	ESPInputSAML       = 5
+)
+
+// Server authentication modes of an ESP enabled Virtual Service, set in
+// VsESP.OutputAuthMode.
+const (
+	ESPOutputNone  = 0
+	ESPOutputBasic = 1
+	ESPOutputForm  = 2
+	ESPOutputKCD   = 3
+)
+
+// SpaceList is a list sent to and received from the LoadMaster as a space
+// separated string.
+type SpaceList []string
+
+func (l SpaceList) MarshalText() ([]byte, error) {
+	return []byte(formatList(l)), nil
+}
+
+func (l *SpaceList) UnmarshalText(text []byte) error {
+	*l = parseList(string(text))
+	return nil
+}
+
+// VsESP is the Edge Security Pack configuration of a Virtual Service,
+// pre-authenticating clients against the SSO domain Domain before they are
+// passed on. Like VsWAF it is filled in by GetVs and only sent by ModifyVs
+// when set.
+type VsESP struct {
+	EspEnabled          bool      `xml:"Success>Data>EspEnabled"`
+	InputAuthMode       int       `xml:"Success>Data>InputAuthMode"`
+	OutputAuthMode      int       `xml:"Success>Data>OutputAuthMode"`
+	Domain              string    `xml:"Success>Data>Domain"`
+	OutConf             string    `xml:"Success>Data>OutConf"`
+	AllowedHosts        SpaceList `xml:"Success>Data>AllowedHosts"`
+	AllowedDirectories  SpaceList `xml:"Success>Data>AllowedDirectories"`
+	ExcludedDirectories SpaceList `xml:"Success>Data>ExcludedDirectories"`
+	Logoff              string    `xml:"Success>Data>Logoff"`
+}
+
+// espPayload is the part of the modvs payload setting the ESP
+// configuration of a Virtual Service.
+type espPayload struct {
+	EspEnabled          string `json:"EspEnabled" qs:"EspEnabled"`
+	InputAuthMode       int    `json:"InputAuthMode" qs:"InputAuthMode"`
+	OutputAuthMode      int    `json:"OutputAuthMode" qs:"OutputAuthMode"`
+	Domain              string `json:"Domain,omitempty" qs:"Domain,omitempty"`
+	OutConf             string `json:"OutConf,omitempty" qs:"OutConf,omitempty"`
+	AllowedHosts        string `json:"AllowedHosts,omitempty" qs:"AllowedHosts,omitempty"`
+	AllowedDirectories  string `json:"AllowedDirectories,omitempty" qs:"AllowedDirectories,omitempty"`
+	ExcludedDirectories string `json:"ExcludedDirectories,omitempty" qs:"ExcludedDirectories,omitempty"`
+	Logoff              string `json:"Logoff,omitempty" qs:"Logoff,omitempty"`
+}
+
+func (e *VsESP) payload() *espPayload {
+	if e == nil {
+		return nil
+	}
+	return &espPayload{
+		EspEnabled:          formatBool(e.EspEnabled),
+		InputAuthMode:       e.InputAuthMode,
+		OutputAuthMode:      e.OutputAuthMode,
+		Domain:              e.Domain,
+		OutConf:             e.OutConf,
+		AllowedHosts:        formatList(e.AllowedHosts),
+		AllowedDirectories:  formatList(e.AllowedDirectories),
+		ExcludedDirectories: formatList(e.ExcludedDirectories),
+		Logoff:              e.Logoff,
+	}
+}
+
+type SSODomains struct {
+	XMLName xml.Name    `xml:"Response"`
+	Domain  []SSODomain `xml:"Success>Data>Domain"`
+}
+
+// SSODomain is a single sign-on domain ESP enabled Virtual Services
+// authenticate clients against. AuthType selects the backend, e.g.
+// "LDAP-Unencrypted", "LDAP-StartTLS", "LDAP-LDAPS", "RADIUS" or
+// "Certificates". LDAP domains either list their Servers or refer to an
+// LdapEndpoint.
+type SSODomain struct {
+	Id                  int       `json:"Id" xml:"Id"`
+	Name                string    `json:"Name" xml:"Name"`
+	AuthType            string    `json:"auth_type" xml:"auth_type"`
+	Servers             SpaceList `json:"server" xml:"server"`
+	ServerSide          bool      `json:"server_side" xml:"server_side"`
+	LogonFmt            string    `json:"logon_fmt" xml:"logon_fmt"`
+	LogonDomain         string    `json:"logon_domain" xml:"logon_domain"`
+	MaxFailedAuths      int       `json:"max_failed_auths" xml:"max_failed_auths"`
+	ResetFailTimeout    int       `json:"reset_fail_tout" xml:"reset_fail_tout"`
+	UnblockTimeout      int       `json:"unblock_tout" xml:"unblock_tout"`
+	SessTimeoutType     string    `json:"sess_tout_type" xml:"sess_tout_type"`
+	SessTimeoutIdle     int       `json:"sess_tout_idle_pub" xml:"sess_tout_idle_pub"`
+	SessTimeoutDuration int       `json:"sess_tout_duration_pub" xml:"sess_tout_duration_pub"`
+	LdapEndpoint        string    `json:"ldap_endpoint" xml:"ldap_endpoint"`
+}
+
+func (c *Client) ListSSODomains() ([]SSODomain, error) {
+	cmd := "showdomain"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var domains SSODomains
+	if err := c.sendCommand(cmd, payload, &domains); err != nil {
+		return nil, err
+	}
+	return domains.Domain, nil
+}
+
+func (c *Client) GetSSODomain(name string) (*SSODomain, error) {
+	cmd := "showdomain"
+	payload := struct {
+		CMD  string `json:"cmd" qs:"-"`
+		Name string `json:"name" qs:"name"`
+	}{
+		CMD:  cmd,
+		Name: name,
+	}
+
+	var domains SSODomains
+	if err := c.sendCommand(cmd, payload, &domains); err != nil {
+		return nil, err
+	}
+	for _, d := range domains.Domain {
+		if strings.EqualFold(d.Name, name) {
+			return &d, nil
+		}
+	}
+	return nil, notFound("SSO domain with name %s not found", name)
+}
+
+// CreateSSODomain adds the SSO domain d.Name and configures it with the
+// other fields of d.
+func (c *Client) CreateSSODomain(d *SSODomain) (*SSODomain, error) {
+	cmd := "adddomain"
+	payload := struct {
+		CMD  string `json:"cmd" qs:"-"`
+		Name string `json:"name" qs:"name"`
+	}{
+		CMD:  cmd,
+		Name: d.Name,
+	}
+
+	if _, err := c.sendApiCommand(cmd, payload); err != nil {
+		return nil, err
+	}
+	return c.ModifySSODomain(d)
+}
+
+func (c *Client) ModifySSODomain(d *SSODomain) (*SSODomain, error) {
+	cmd := "moddomain"
+	payload := struct {
+		CMD                 string `json:"cmd" qs:"-"`
+		Name                string `json:"name" qs:"name"`
+		AuthType            string `json:"auth_type,omitempty" qs:"auth_type,omitempty"`
+		Servers             string `json:"server,omitempty" qs:"server,omitempty"`
+		ServerSide          string `json:"server_side" qs:"server_side"`
+		LogonFmt            string `json:"logon_fmt,omitempty" qs:"logon_fmt,omitempty"`
+		LogonDomain         string `json:"logon_domain,omitempty" qs:"logon_domain,omitempty"`
+		MaxFailedAuths      int    `json:"max_failed_auths,omitempty" qs:"max_failed_auths,omitempty"`
+		ResetFailTimeout    int    `json:"reset_fail_tout,omitempty" qs:"reset_fail_tout,omitempty"`
+		UnblockTimeout      int    `json:"unblock_tout,omitempty" qs:"unblock_tout,omitempty"`
+		SessTimeoutType     string `json:"sess_tout_type,omitempty" qs:"sess_tout_type,omitempty"`
+		SessTimeoutIdle     int    `json:"sess_tout_idle_pub,omitempty" qs:"sess_tout_idle_pub,omitempty"`
+		SessTimeoutDuration int    `json:"sess_tout_duration_pub,omitempty" qs:"sess_tout_duration_pub,omitempty"`
+		LdapEndpoint        string `json:"ldap_endpoint,omitempty" qs:"ldap_endpoint,omitempty"`
+	}{
+		CMD:                 cmd,
+		Name:                d.Name,
+		AuthType:            d.AuthType,
+		Servers:             formatList(d.Servers),
+		ServerSide:          formatBool(d.ServerSide),
+		LogonFmt:            d.LogonFmt,
+		LogonDomain:         d.LogonDomain,
+		MaxFailedAuths:      d.MaxFailedAuths,
+		ResetFailTimeout:    d.ResetFailTimeout,
+		UnblockTimeout:      d.UnblockTimeout,
+		SessTimeoutType:     d.SessTimeoutType,
+		SessTimeoutIdle:     d.SessTimeoutIdle,
+		SessTimeoutDuration: d.SessTimeoutDuration,
+		LdapEndpoint:        d.LdapEndpoint,
+	}
+
+	if _, err := c.sendApiCommand(cmd, payload); err != nil {
+		return nil, err
+	}
+	return c.GetSSODomain(d.Name)
+}
+
+func (c *Client) DeleteSSODomain(name string) (*ApiResponse, error) {
+	cmd := "deldomain"
+	payload := struct {
+		CMD  string `json:"cmd" qs:"-"`
+		Name string `json:"name" qs:"name"`
+	}{
+		CMD:  cmd,
+		Name: name,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+type LdapEndpoints struct {
+	XMLName  xml.Name       `xml:"Response"`
+	Endpoint []LdapEndpoint `json:"LDAPEndPoint" xml:"Success>Data>LDAPEndPoint"`
+}
+
+// LdapEndpoint is a set of LDAP servers SSO domains can share. Type is one
+// of "Unencrypted", "StartTLS" or "LDAPS". AdminPass is only sent, the
+// LoadMaster does not return it.
+type LdapEndpoint struct {
+	Name               string    `json:"name" xml:"name"`
+	Servers            SpaceList `json:"server" xml:"server"`
+	Type               string    `json:"ldaptype" xml:"ldaptype"`
+	ValidationInterval int       `json:"vinterval" xml:"vinterval"`
+	ReferralCount      int       `json:"referralcount" xml:"referralcount"`
+	Timeout            int       `json:"timeout" xml:"timeout"`
+	AdminUser          string    `json:"adminuser" xml:"adminuser"`
+	AdminPass          string    `json:"-" xml:"-"`
+}
+
+func (c *Client) ListLdapEndpoints() ([]LdapEndpoint, error) {
+	cmd := "showldaplist"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var endpoints LdapEndpoints
+	if err := c.sendCommand(cmd, payload, &endpoints); err != nil {
+		return nil, err
+	}
+	return endpoints.Endpoint, nil
+}
+
+func (c *Client) GetLdapEndpoint(name string) (*LdapEndpoint, error) {
+	cmd := "showldapendpoint"
+	payload := struct {
+		CMD  string `json:"cmd" qs:"-"`
+		Name string `json:"name" qs:"name"`
+	}{
+		CMD:  cmd,
+		Name: name,
+	}
+
+	var endpoints LdapEndpoints
+	if err := c.sendCommand(cmd, payload, &endpoints); err != nil {
+		return nil, err
+	}
+	for _, e := range endpoints.Endpoint {
+		if e.Name == name {
+			return &e, nil
+		}
+	}
+	return nil, notFound("LDAP endpoint with name %s not found", name)
+}
+
+// CreateLdapEndpoint adds the LDAP endpoint e. On API version 1 its
+// AdminPass is sent as query parameter, see PasswordInQuery.
+func (c *Client) CreateLdapEndpoint(e *LdapEndpoint) (*LdapEndpoint, error) {
+	return c.ldapEndpointCommand("addldapendpoint", e)
+}
+
+// ModifyLdapEndpoint changes the LDAP endpoint named e.Name. On API version
+// 1 its AdminPass is sent as query parameter, see PasswordInQuery.
+func (c *Client) ModifyLdapEndpoint(e *LdapEndpoint) (*LdapEndpoint, error) {
+	return c.ldapEndpointCommand("modifyldapendpoint", e)
+}
+
+func (c *Client) ldapEndpointCommand(cmd string, e *LdapEndpoint) (*LdapEndpoint, error) {
+	if e.AdminPass != "" {
+		if err := c.checkPasswordInQuery(); err != nil {
+			return nil, err
+		}
+	}
+
+	payload := struct {
+		CMD                string `json:"cmd" qs:"-"`
+		Name               string `json:"name" qs:"name"`
+		Servers            string `json:"server,omitempty" qs:"server,omitempty"`
+		Type               string `json:"ldaptype,omitempty" qs:"ldaptype,omitempty"`
+		ValidationInterval int    `json:"vinterval,omitempty" qs:"vinterval,omitempty"`
+		ReferralCount      int    `json:"referralcount,omitempty" qs:"referralcount,omitempty"`
+		Timeout            int    `json:"timeout,omitempty" qs:"timeout,omitempty"`
+		AdminUser          string `json:"adminuser,omitempty" qs:"adminuser,omitempty"`
+		AdminPass          string `json:"adminpass,omitempty" qs:"adminpass,omitempty"`
+	}{
+		CMD:                cmd,
+		Name:               e.Name,
+		Servers:            formatList(e.Servers),
+		Type:               e.Type,
+		ValidationInterval: e.ValidationInterval,
+		ReferralCount:      e.ReferralCount,
+		Timeout:            e.Timeout,
+		AdminUser:          e.AdminUser,
+		AdminPass:          e.AdminPass,
+	}
+
+	if _, err := c.sendApiCommand(cmd, payload); err != nil {
+		return nil, err
+	}
+	return c.GetLdapEndpoint(e.Name)
+}
+
+func (c *Client) DeleteLdapEndpoint(name string) (*ApiResponse, error) {
+	cmd := "deleteldapendpoint"
+	payload := struct {
+		CMD  string `json:"cmd" qs:"-"`
+		Name string `json:"name" qs:"name"`
+	}{
+		CMD:  cmd,
+		Name: name,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
@@ -0,0 +1,147 @@
+package lmclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestModifyVsESP(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+		datafile   string
+	}{
+		{2, "/accessv2", "test_data/modvs.json"},
+		{1, "/access/modvs?AllowedDirectories=%2Fapp%2F%2A&AllowedHosts=app.example.com+www.example.com&Domain=EXAMPLE.COM&Enable=Y&EspEnabled=Y&InputAuthMode=2&Logoff=%2Flogout&OutputAuthMode=1&forcel4=0&forcel7=1&port=&vs=1&vsaddress=", "test_data/modvs.xml"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			content, err := ioutil.ReadFile(tc.datafile)
+			ok(t, err)
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				equals(t, req.URL.String(), tc.url)
+				_, err := rw.Write([]byte(content))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: tc.apiversion}
+
+			_, err = client.ModifyVs(&Vs{
+				Index:  1,
+				Enable: true,
+				ESP: &VsESP{
+					EspEnabled:         true,
+					InputAuthMode:      ESPInputForm,
+					OutputAuthMode:     ESPOutputBasic,
+					Domain:             "EXAMPLE.COM",
+					AllowedHosts:       []string{"app.example.com", "www.example.com"},
+					AllowedDirectories: []string{"/app/*"},
+					Logoff:             "/logout",
+				},
+			})
+			ok(t, err)
+		})
+	}
+}
+
+func TestGetVsESP(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{"showvs": "showvs"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			vs, err := client.GetVs(1)
+			ok(t, err)
+
+			equals(t, true, vs.ESP != nil)
+			equals(t, false, vs.ESP.EspEnabled)
+			equals(t, ESPInputNone, vs.ESP.InputAuthMode)
+			equals(t, ESPOutputNone, vs.ESP.OutputAuthMode)
+		})
+	}
+}
+
+func TestCreateSSODomain(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{
+				"adddomain":  "ok",
+				"moddomain":  "ok",
+				"showdomain": "showdomain",
+			}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			d, err := client.CreateSSODomain(&SSODomain{
+				Name:       "EXAMPLE.COM",
+				AuthType:   "LDAP-StartTLS",
+				Servers:    []string{"10.0.0.10", "10.0.0.11"},
+				ServerSide: true,
+			})
+			ok(t, err)
+
+			equals(t, []string{"adddomain", "moddomain", "showdomain"}, cmds)
+			equals(t, 1, d.Id)
+			equals(t, SpaceList{"10.0.0.10", "10.0.0.11"}, d.Servers)
+			equals(t, true, d.ServerSide)
+			equals(t, 900, d.SessTimeoutIdle)
+
+			_, err = client.GetSSODomain("OTHER.COM")
+			equals(t, "SSO domain with name OTHER.COM not found", err.Error())
+		})
+	}
+}
+
+func TestGetLdapEndpoint(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{"showldapendpoint": "showldapendpoint"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			e, err := client.GetLdapEndpoint("corp")
+			ok(t, err)
+
+			equals(t, SpaceList{"10.0.0.10:636"}, e.Servers)
+			equals(t, "LDAPS", e.Type)
+			equals(t, 60, e.ValidationInterval)
+			equals(t, "svc-loadmaster", e.AdminUser)
+		})
+	}
+}
+
+func TestCreateLdapEndpoint(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{
+				"addldapendpoint":  "ok",
+				"showldapendpoint": "showldapendpoint",
+			}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			endpoint := &LdapEndpoint{Name: "corp", Servers: SpaceList{"10.0.0.10:636"}, Type: "LDAPS", AdminUser: "svc-loadmaster", AdminPass: "s3cr3t"}
+			if apiversion == 1 {
+				_, err := client.CreateLdapEndpoint(endpoint)
+				equals(t, "Password would be sent as query parameter with API version 1, set PasswordInQuery to allow it", err.Error())
+				equals(t, 0, len(cmds))
+				client.PasswordInQuery = true
+			}
+
+			e, err := client.CreateLdapEndpoint(endpoint)
+			ok(t, err)
+			equals(t, "svc-loadmaster", e.AdminUser)
+			equals(t, []string{"addldapendpoint", "showldapendpoint"}, cmds)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package lmclient
+
+import (
+	"net/netip"
+	"path"
+	"strings"
+)
+
+// VsFilter selects Virtual Services in FindVs. Empty fields match any
+// value. NickName is a glob pattern as understood by path.Match.
+type VsFilter struct {
+	Address  string
+	Port     string
+	Protocol string
+	NickName string
+	Status   string
+}
+
+func (f *VsFilter) match(vs *VsListed) bool {
+	if f.Address != "" && !sameAddress(f.Address, vs.Address) {
+		return false
+	}
+	if f.Port != "" && f.Port != vs.Port {
+		return false
+	}
+	if f.Protocol != "" && !strings.EqualFold(f.Protocol, vs.Protocol) {
+		return false
+	}
+	if f.NickName != "" {
+		if matched, _ := path.Match(f.NickName, vs.NickName); !matched {
+			return false
+		}
+	}
+	if f.Status != "" && !strings.EqualFold(f.Status, vs.Status) {
+		return false
+	}
+	return true
+}
+
+// RsFilter selects Real Servers in FindRs. Zero fields match any value.
+type RsFilter struct {
+	VSIndex int
+	Addr    string
+	Port    int
+	Status  string
+}
+
+func (f *RsFilter) match(rs *Rs) bool {
+	if f.Addr != "" && !sameAddress(f.Addr, rs.Addr) {
+		return false
+	}
+	if f.Port != 0 && f.Port != rs.Port {
+		return false
+	}
+	if f.Status != "" && !strings.EqualFold(f.Status, rs.Status) {
+		return false
+	}
+	return true
+}
+
+// NormalizeAddress returns the canonical form of an IP address, so IPv6
+// addresses written differently compare equal. Brackets around IPv6
+// addresses are removed. Anything that is no IP address, like a host name,
+// is returned in lower case.
+func NormalizeAddress(addr string) string {
+	addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return strings.ToLower(addr)
+	}
+	return ip.Unmap().String()
+}
+
+func sameAddress(a string, b string) bool {
+	return NormalizeAddress(a) == NormalizeAddress(b)
+}
+
+// FindVs returns the Virtual Services matching filter.
+func (c *Client) FindVs(filter *VsFilter) ([]VsListed, error) {
+	vss, err := c.GetAllVs()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []VsListed
+	for i := range vss {
+		if filter.match(&vss[i]) {
+			res = append(res, vss[i])
+		}
+	}
+	return res, nil
+}
+
+// FindRs returns the Real Servers matching filter, looking at all Virtual
+// Services unless filter.VSIndex is set.
+func (c *Client) FindRs(filter *RsFilter) ([]Rs, error) {
+	indexes := []int{filter.VSIndex}
+	if filter.VSIndex == 0 {
+		vss, err := c.GetAllVs()
+		if err != nil {
+			return nil, err
+		}
+		indexes = indexes[:0]
+		for _, vs := range vss {
+			indexes = append(indexes, vs.Index)
+		}
+	}
+
+	var res []Rs
+	for _, index := range indexes {
+		vs, err := c.GetVs(index)
+		if err != nil {
+			return nil, err
+		}
+		for i := range vs.Rs {
+			if filter.match(&vs.Rs[i]) {
+				res = append(res, vs.Rs[i])
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,79 @@
+package lmclient
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	testCases := []struct {
+		addr string
+		exp  string
+	}{
+		{"192.168.1.239", "192.168.1.239"},
+		{"2001:DB8:0:0::1", "2001:db8::1"},
+		{"[2001:db8::1]", "2001:db8::1"},
+		{"::ffff:192.168.1.239", "192.168.1.239"},
+		{"Web01.Example.com", "web01.example.com"},
+	}
+	for _, tc := range testCases {
+		equals(t, tc.exp, NormalizeAddress(tc.addr))
+	}
+}
+
+func TestFindVs(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		address    string
+	}{
+		{2, "192.168.1.239"},
+		{1, "192.168.1.222"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, tc.apiversion, map[string]string{"listvs": "listvs"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: tc.apiversion}
+
+			vss, err := client.FindVs(&VsFilter{Address: tc.address, Port: "80", Protocol: "TCP", NickName: "f*"})
+			ok(t, err)
+			equals(t, 1, len(vss))
+			equals(t, "foo", vss[0].NickName)
+			equals(t, "Down", vss[0].Status)
+			equals(t, true, vss[0].Enable)
+
+			vss, err = client.FindVs(&VsFilter{Status: "Up"})
+			ok(t, err)
+			equals(t, 0, len(vss))
+		})
+	}
+}
+
+func TestFindRs(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{
+				"listvs": "listvs",
+				"showvs": "showvsclone",
+			}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			rss, err := client.FindRs(&RsFilter{Port: 8080, Status: "down"})
+			ok(t, err)
+			equals(t, []string{"listvs", "showvs"}, cmds)
+			equals(t, 1, len(rss))
+			equals(t, "10.10.10.11", rss[0].Addr)
+			equals(t, false, rss[0].Enable)
+
+			cmds = nil
+			rss, err = client.FindRs(&RsFilter{VSIndex: 1, Addr: "::ffff:10.10.10.10"})
+			ok(t, err)
+			equals(t, []string{"showvs"}, cmds)
+			equals(t, 1, len(rss))
+			equals(t, 1, rss[0].RsIndex)
+		})
+	}
+}
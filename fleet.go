@@ -0,0 +1,187 @@
+package lmclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Fleet holds a set of named clients, one per LoadMaster, and runs
+// operations against all of them concurrently.
+type Fleet struct {
+	Parallelism int
+
+	mu      sync.RWMutex
+	clients map[string]*Client
+}
+
+// FleetError collects the errors returned by individual LoadMasters
+// during a fleet-wide operation, keyed by LoadMaster name.
+type FleetError struct {
+	Errors map[string]error
+}
+
+func (e *FleetError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", name, e.Errors[name]))
+	}
+	return fmt.Sprintf("%d of the LoadMasters failed: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// NewFleet returns an empty fleet running at most parallelism operations
+// at once. A parallelism below 1 runs one operation at a time.
+func NewFleet(parallelism int) *Fleet {
+	return &Fleet{
+		Parallelism: parallelism,
+		clients:     make(map[string]*Client),
+	}
+}
+
+// Add registers a client under name, replacing any client previously
+// registered under the same name.
+func (f *Fleet) Add(name string, c *Client) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.clients == nil {
+		f.clients = make(map[string]*Client)
+	}
+	f.clients[name] = c
+}
+
+func (f *Fleet) Remove(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.clients, name)
+}
+
+func (f *Fleet) Get(name string) (*Client, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	c, found := f.clients[name]
+	if !found {
+		return nil, notFound("LoadMaster with name %s not found in fleet", name)
+	}
+	return c, nil
+}
+
+// Names returns the names of all LoadMasters in the fleet, sorted.
+func (f *Fleet) Names() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	names := make([]string, 0, len(f.clients))
+	for name := range f.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Each calls fn for every LoadMaster in the fleet, running at most
+// Parallelism calls at once. LoadMasters not yet started when ctx is
+// cancelled are reported with the context error, fn is expected to pass ctx
+// on to the requests it sends. If any call fails the
+// returned error is a *FleetError.
+func (f *Fleet) Each(ctx context.Context, fn func(ctx context.Context, name string, c *Client) error) error {
+	f.mu.RLock()
+	clients := make(map[string]*Client, len(f.clients))
+	for name, c := range f.clients {
+		clients[name] = c
+	}
+	f.mu.RUnlock()
+
+	parallelism := f.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	sem := make(chan struct{}, parallelism)
+
+	var mu sync.Mutex
+	errs := make(map[string]error)
+	var wg sync.WaitGroup
+	for name, c := range clients {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			errs[name] = ctx.Err()
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(name string, c *Client) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, name, c); err != nil {
+				mu.Lock()
+				errs[name] = err
+				mu.Unlock()
+			}
+		}(name, c)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return &FleetError{Errors: errs}
+	}
+	return nil
+}
+
+// GetAllVs lists the Virtual Services of every LoadMaster in the fleet.
+// Results from LoadMasters that answered are returned even if others failed.
+func (f *Fleet) GetAllVs(ctx context.Context) (map[string][]VsListed, error) {
+	var mu sync.Mutex
+	res := make(map[string][]VsListed)
+	err := f.Each(ctx, func(ctx context.Context, name string, c *Client) error {
+		vss, err := c.getAllVs(ctx)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		res[name] = vss
+		mu.Unlock()
+		return nil
+	})
+	return res, err
+}
+
+// FindVsByName returns, keyed by LoadMaster name, the Virtual Service with
+// the nickname on every LoadMaster of the fleet having one. LoadMasters with
+// several are reported with an *AmbiguousError in the *FleetError.
+func (f *Fleet) FindVsByName(ctx context.Context, nickname string) (map[string]VsListed, error) {
+	var mu sync.Mutex
+	res := make(map[string]VsListed)
+	err := f.Each(ctx, func(ctx context.Context, name string, c *Client) error {
+		vss, err := c.getAllVs(ctx)
+		if err != nil {
+			return err
+		}
+		vs, err := vsByName(vss, nickname)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		res[name] = *vs
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	if len(res) == 0 {
+		return nil, notFound("Virtual Service with name %s not found in fleet", nickname)
+	}
+	return res, nil
+}
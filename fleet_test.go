@@ -0,0 +1,97 @@
+package lmclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFleetFindVsByName(t *testing.T) {
+	content, err := ioutil.ReadFile("test_data/listvs.json")
+	ok(t, err)
+	dup, err := ioutil.ReadFile("test_data/listvsdup.json")
+	ok(t, err)
+	// Start a local HTTP server for a healthy, a failing and an ambiguous
+	// LoadMaster
+	good := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, err := rw.Write(content)
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+	defer good.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer bad.Close()
+	ambiguous := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, err := rw.Write(dup)
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+	defer ambiguous.Close()
+
+	fleet := NewFleet(2)
+	fleet.Add("lm1", &Client{HttpClient: good.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: good.URL, Version: 2})
+	fleet.Add("lm2", &Client{HttpClient: bad.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: bad.URL, Version: 2})
+	fleet.Add("lm3", &Client{HttpClient: ambiguous.Client(), ApiKey: "bar", RestUrl: ambiguous.URL, Version: 2})
+	equals(t, []string{"lm1", "lm2", "lm3"}, fleet.Names())
+
+	res, err := fleet.FindVsByName(context.Background(), "foo")
+	fe, isFleetError := err.(*FleetError)
+	equals(t, true, isFleetError)
+	equals(t, 2, len(fe.Errors))
+	equals(t, true, fe.Errors["lm2"] != nil)
+	equals(t, true, errors.Is(fe.Errors["lm3"], ErrAmbiguous))
+	equals(t, 1, len(res))
+	equals(t, 1, res["lm1"].Index)
+
+	fleet.Remove("lm2")
+	fleet.Remove("lm3")
+	res, err = fleet.FindVsByName(context.Background(), "foo")
+	ok(t, err)
+	equals(t, 1, len(res))
+
+	_, err = fleet.FindVsByName(context.Background(), "missing")
+	equals(t, "Virtual Service with name missing not found in fleet", err.Error())
+}
+
+func TestFleetGetAllVs(t *testing.T) {
+	content, err := ioutil.ReadFile("test_data/listvs.json")
+	ok(t, err)
+	// Start a local HTTP server for a healthy and a hanging LoadMaster
+	good := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, err := rw.Write(content)
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+	defer good.Close()
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	defer hanging.Close()
+	defer close(release)
+
+	fleet := NewFleet(2)
+	fleet.Add("lm1", &Client{HttpClient: good.Client(), ApiKey: "bar", RestUrl: good.URL, Version: 2})
+	fleet.Add("lm2", &Client{HttpClient: hanging.Client(), ApiKey: "bar", RestUrl: hanging.URL, Version: 2})
+
+	// The request already sent to lm2 is cancelled with the context
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	res, err := fleet.GetAllVs(ctx)
+	fe, isFleetError := err.(*FleetError)
+	equals(t, true, isFleetError)
+	equals(t, 1, len(fe.Errors))
+	equals(t, true, errors.Is(fe.Errors["lm2"], context.DeadlineExceeded))
+	equals(t, 1, len(res))
+	equals(t, "foo", res["lm1"][0].NickName)
+}
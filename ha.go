@@ -0,0 +1,224 @@
+package lmclient
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+)
+
+type HAStatus struct {
+	XMLName    xml.Name `xml:"Response"`
+	Mode       string   `json:"HaMode" xml:"Success>Data>HaMode"`
+	Role       string   `json:"HaRole" xml:"Success>Data>HaRole"`
+	PeerState  string   `json:"HaPeerState" xml:"Success>Data>HaPeerState"`
+	SyncStatus string   `json:"HaSyncStatus" xml:"Success>Data>HaSyncStatus"`
+}
+
+func (s *HAStatus) IsActive() bool {
+	return s.Role == "active"
+}
+
+func (s *HAStatus) InSync() bool {
+	return s.SyncStatus == "insync"
+}
+
+func (c *Client) GetHAStatus() (*HAStatus, error) {
+	cmd := "hastatus"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var status HAStatus
+	if err := c.sendCommand(cmd, payload, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// HAClient talks to both units of a LoadMaster HA pair, sending
+// configuration changes to whichever unit is currently active.
+type HAClient struct {
+	Units [2]*Client
+}
+
+func NewHAClient(first *Client, second *Client) *HAClient {
+	return &HAClient{
+		Units: [2]*Client{first, second},
+	}
+}
+
+// haState is the HA status of both units, queried once each.
+type haState struct {
+	active   int
+	statuses [2]*HAStatus
+	errs     [2]error
+}
+
+// state queries the HA status of both units and picks the active one. It
+// fails if neither or both report the active role.
+func (h *HAClient) state() (*haState, error) {
+	s := &haState{active: -1}
+	for i, u := range h.Units {
+		s.statuses[i], s.errs[i] = u.GetHAStatus()
+		if s.errs[i] != nil {
+			s.errs[i] = fmt.Errorf("%s: %w", u.RestUrl, s.errs[i])
+			continue
+		}
+		if s.statuses[i].IsActive() {
+			if s.active >= 0 {
+				return nil, fmt.Errorf("Both HA units %s and %s report the active role", h.Units[0].RestUrl, h.Units[1].RestUrl)
+			}
+			s.active = i
+		}
+	}
+	if s.active >= 0 {
+		return s, nil
+	}
+	if s.errs[0] != nil || s.errs[1] != nil {
+		var errs []error
+		for _, err := range s.errs {
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return nil, fmt.Errorf("No active HA unit found: %v", errs)
+	}
+	return nil, fmt.Errorf("No active HA unit found, roles are %s and %s", s.statuses[0].Role, s.statuses[1].Role)
+}
+
+// Active returns the unit currently reporting the active role.
+func (h *HAClient) Active() (*Client, error) {
+	s, err := h.state()
+	if err != nil {
+		return nil, err
+	}
+	return h.Units[s.active], nil
+}
+
+// Standby returns the unit not currently reporting the active role.
+func (h *HAClient) Standby() (*Client, error) {
+	s, err := h.state()
+	if err != nil {
+		return nil, err
+	}
+	return h.Units[1-s.active], nil
+}
+
+// VerifySync checks that the standby unit reports its configuration as
+// synchronised and that it knows the same Virtual Services as the active
+// unit.
+func (h *HAClient) VerifySync() error {
+	s, err := h.state()
+	if err != nil {
+		return err
+	}
+	active, standby := h.Units[s.active], h.Units[1-s.active]
+
+	status := s.statuses[1-s.active]
+	if status == nil {
+		return s.errs[1-s.active]
+	}
+	if !status.InSync() {
+		return fmt.Errorf("Standby unit %s not in sync: %s", standby.RestUrl, status.SyncStatus)
+	}
+
+	avs, err := active.GetAllVs()
+	if err != nil {
+		return err
+	}
+	svs, err := standby.GetAllVs()
+	if err != nil {
+		return err
+	}
+	if !sameVsList(avs, svs) {
+		return fmt.Errorf("Standby unit %s has different Virtual Services than active unit %s", standby.RestUrl, active.RestUrl)
+	}
+	return nil
+}
+
+func sameVsList(a []VsListed, b []VsListed) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	key := func(v VsListed) string { return fmt.Sprintf("%d/%s", v.Index, v.NickName) }
+	ka := make([]string, len(a))
+	kb := make([]string, len(b))
+	for i := range a {
+		ka[i] = key(a[i])
+		kb[i] = key(b[i])
+	}
+	sort.Strings(ka)
+	sort.Strings(kb)
+	for i := range ka {
+		if ka[i] != kb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *HAClient) GetAllVs() ([]VsListed, error) {
+	c, err := h.Active()
+	if err != nil {
+		return nil, err
+	}
+	return c.GetAllVs()
+}
+
+func (h *HAClient) GetVs(index int) (*Vs, error) {
+	c, err := h.Active()
+	if err != nil {
+		return nil, err
+	}
+	return c.GetVs(index)
+}
+
+func (h *HAClient) CreateVs(v *Vs) (*Vs, error) {
+	c, err := h.Active()
+	if err != nil {
+		return nil, err
+	}
+	return c.CreateVs(v)
+}
+
+func (h *HAClient) ModifyVs(v *Vs) (*Vs, error) {
+	c, err := h.Active()
+	if err != nil {
+		return nil, err
+	}
+	return c.ModifyVs(v)
+}
+
+func (h *HAClient) DeleteVs(index int) (*ApiResponse, error) {
+	c, err := h.Active()
+	if err != nil {
+		return nil, err
+	}
+	return c.DeleteVs(index)
+}
+
+func (h *HAClient) CreateRs(r *Rs) (*Rs, error) {
+	c, err := h.Active()
+	if err != nil {
+		return nil, err
+	}
+	return c.CreateRs(r)
+}
+
+func (h *HAClient) ModifyRs(r *Rs) (*ApiResponse, error) {
+	c, err := h.Active()
+	if err != nil {
+		return nil, err
+	}
+	return c.ModifyRs(r)
+}
+
+func (h *HAClient) DeleteRs(index int, vsindex int) (*ApiResponse, error) {
+	c, err := h.Active()
+	if err != nil {
+		return nil, err
+	}
+	return c.DeleteRs(index, vsindex)
+}
@@ -0,0 +1,144 @@
+package lmclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetHAStatus(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+		datafile   string
+	}{
+		{2, "/accessv2", "test_data/hastatus.json"},
+		{1, "/access/hastatus", "test_data/hastatus.xml"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			content, err := ioutil.ReadFile(tc.datafile)
+			ok(t, err)
+			// Start a local HTTP server
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// Test request parameters
+				equals(t, req.URL.String(), tc.url)
+				// Send response to be tested
+				_, err := rw.Write([]byte(content))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
+
+			status, err := client.GetHAStatus()
+			ok(t, err)
+
+			equals(t, status.Mode, "1")
+			equals(t, status.IsActive(), true)
+			equals(t, status.PeerState, "up")
+			equals(t, status.InSync(), true)
+		})
+	}
+}
+
+func TestHAClientActive(t *testing.T) {
+	content, err := ioutil.ReadFile("test_data/hastatus.json")
+	ok(t, err)
+	active := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, err := rw.Write(content)
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+	defer active.Close()
+	standby := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, err := rw.Write([]byte(strings.Replace(string(content), "active", "standby", 1)))
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+	defer standby.Close()
+
+	first := &Client{HttpClient: standby.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: standby.URL, Version: 2}
+	second := &Client{HttpClient: active.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: active.URL, Version: 2}
+	ha := NewHAClient(first, second)
+
+	c, err := ha.Active()
+	ok(t, err)
+	equals(t, second, c)
+
+	c, err = ha.Standby()
+	ok(t, err)
+	equals(t, first, c)
+}
+
+func TestHAClientVerifySync(t *testing.T) {
+	testCases := []struct {
+		name    string
+		active  map[string]string
+		standby map[string]string
+		err     string
+	}{
+		{
+			"in sync",
+			map[string]string{"hastatus": "hastatus", "listvs": "listvs"},
+			map[string]string{"hastatus": "hastatusstandby", "listvs": "listvs"},
+			"",
+		},
+		{
+			"out of sync",
+			map[string]string{"hastatus": "hastatus", "listvs": "listvs"},
+			map[string]string{"hastatus": "hastatusoutofsync", "listvs": "listvs"},
+			"Standby unit STANDBY not in sync: outofsync",
+		},
+		{
+			"different Virtual Services",
+			map[string]string{"hastatus": "hastatus", "listvs": "listvs"},
+			map[string]string{"hastatus": "hastatusstandby", "listvs": "listvsdup"},
+			"Standby unit STANDBY has different Virtual Services than active unit ACTIVE",
+		},
+		{
+			"split brain",
+			map[string]string{"hastatus": "hastatus"},
+			map[string]string{"hastatus": "hastatus"},
+			"Both HA units ACTIVE and STANDBY report the active role",
+		},
+		{
+			"both standby",
+			map[string]string{"hastatus": "hastatusstandby"},
+			map[string]string{"hastatus": "hastatusstandby"},
+			"No active HA unit found, roles are standby and standby",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var acmds, scmds []string
+			active := newCommandServer(t, 2, tc.active, &acmds)
+			defer active.Close()
+			standby := newCommandServer(t, 2, tc.standby, &scmds)
+			defer standby.Close()
+
+			first := &Client{HttpClient: active.Client(), ApiKey: "bar", RestUrl: active.URL, Version: 2}
+			second := &Client{HttpClient: standby.Client(), ApiKey: "bar", RestUrl: standby.URL, Version: 2}
+			ha := NewHAClient(first, second)
+
+			err := ha.VerifySync()
+			if tc.err == "" {
+				ok(t, err)
+				equals(t, []string{"hastatus", "listvs"}, acmds)
+				equals(t, []string{"hastatus", "listvs"}, scmds)
+				return
+			}
+			msg := strings.NewReplacer(active.URL, "ACTIVE", standby.URL, "STANDBY").Replace(err.Error())
+			equals(t, tc.err, msg)
+			equals(t, "hastatus", acmds[0])
+			equals(t, "hastatus", scmds[0])
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package lmclient
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+)
+
+// LicenseInfo describes the license of a LoadMaster. Dates are passed on
+// as the LoadMaster formats them, "unlimited" for perpetual licenses.
+type LicenseInfo struct {
+	XMLName        xml.Name `xml:"Response" json:"-"`
+	UUID           string   `json:"uuid" xml:"Success>Data>uuid"`
+	LicenseType    string   `xml:"Success>Data>LicenseType"`
+	LicenseStatus  string   `xml:"Success>Data>LicenseStatus"`
+	ActivationDate string   `xml:"Success>Data>ActivationDate"`
+	LicensedUntil  string   `xml:"Success>Data>LicensedUntil"`
+	SupportLevel   string   `xml:"Success>Data>SupportLevel"`
+	SupportUntil   string   `xml:"Success>Data>SupportUntil"`
+	// Bandwidth is the licensed throughput in Mbit/s, zero if unlimited.
+	Bandwidth int `xml:"Success>Data>Bandwidth"`
+	MaxVS     int `xml:"Success>Data>MaxVS"`
+	MaxRS     int `xml:"Success>Data>MaxRS"`
+}
+
+// Activated reports whether the license has been activated, as opposed to
+// the temporary license of a new appliance.
+func (l *LicenseInfo) Activated() bool {
+	return l.ActivationDate != "" && !strings.HasPrefix(strings.ToLower(l.LicenseStatus), "temp")
+}
+
+func (c *Client) GetLicenseInfo() (*LicenseInfo, error) {
+	cmd := "licenseinfo"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var info LicenseInfo
+	if err := c.sendCommand(cmd, payload, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// GetVersion returns the firmware version of the LoadMaster, as cached by
+// ApplianceInfo.
+func (c *Client) GetVersion() (string, error) {
+	info, err := c.ApplianceInfo()
+	if err != nil {
+		return "", err
+	}
+	return info.FirmwareVersion, nil
+}
+
+// HardwareInfo describes the appliance a LoadMaster runs on.
+type HardwareInfo struct {
+	Model        string
+	Serial       string
+	CPUs         int
+	MemoryMB     int
+	DiskGB       float64
+	MACAddresses []string
+}
+
+func (c *Client) GetHardwareInfo() (*HardwareInfo, error) {
+	p, err := c.getParams([]string{"serialnumber", "model"})
+	if err != nil {
+		return nil, err
+	}
+	s, err := c.getStats()
+	if err != nil {
+		return nil, err
+	}
+	ifaces, err := c.GetAllInterfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	hw := &HardwareInfo{
+		Model:    p["model"],
+		Serial:   p["serialnumber"],
+		CPUs:     len(s.CPU.Cores),
+		MemoryMB: s.Memory.Used + s.Memory.Free,
+	}
+	for _, d := range s.Disks {
+		if !nestedPartition(d.Name, s.Disks) {
+			hw.DiskGB += d.TotalGB
+		}
+	}
+	for _, iface := range ifaces {
+		if iface.MACAddress != "" {
+			hw.MACAddresses = append(hw.MACAddresses, iface.MACAddress)
+		}
+	}
+	return hw, nil
+}
+
+// nestedPartition reports whether the partition mounted at name lies within
+// another of disks, its size then being part of that one's.
+func nestedPartition(name string, disks []DiskUsage) bool {
+	for _, d := range disks {
+		parent := strings.TrimSuffix(d.Name, "/") + "/"
+		if d.Name != name && strings.HasPrefix(name, parent) {
+			return true
+		}
+	}
+	return false
+}
+
+// SystemUsage is the current load of a LoadMaster. CPU figures are percent
+// of the time of all CPUs.
+type SystemUsage struct {
+	CPUUser           int
+	CPUSystem         int
+	CPUIdle           int
+	CPUIOWait         int
+	MemoryUsedMB      int
+	MemoryFreeMB      int
+	MemoryUsedPercent int
+	Disks             []DiskUsage
+}
+
+type DiskUsage struct {
+	Name        string  `json:"name" xml:"name"`
+	TotalGB     float64 `json:"GBtotal" xml:"GBtotal"`
+	UsedGB      float64 `json:"GBused" xml:"GBused"`
+	UsedPercent float64 `json:"percentused" xml:"percentused"`
+}
+
+func (c *Client) GetSystemUsage() (*SystemUsage, error) {
+	s, err := c.getStats()
+	if err != nil {
+		return nil, err
+	}
+	return &SystemUsage{
+		CPUUser:           s.CPU.Total.User,
+		CPUSystem:         s.CPU.Total.System,
+		CPUIdle:           s.CPU.Total.Idle,
+		CPUIOWait:         s.CPU.Total.IOWaiting,
+		MemoryUsedMB:      s.Memory.Used,
+		MemoryFreeMB:      s.Memory.Free,
+		MemoryUsedPercent: s.Memory.UsedPercent,
+		Disks:             s.Disks,
+	}, nil
+}
+
+type stats struct {
+	XMLName xml.Name    `xml:"Response"`
+	CPU     cpuStats    `xml:"Success>Data>CPU"`
+	Memory  memoryStats `xml:"Success>Data>Memory"`
+	Disks   []DiskUsage `json:"DiskUsage" xml:"Success>Data>DiskUsage>partition"`
+}
+
+type cpuUsage struct {
+	User      int `xml:"User"`
+	System    int `xml:"System"`
+	Idle      int `xml:"Idle"`
+	IOWaiting int `xml:"IOWaiting"`
+}
+
+// cpuStats holds the usage of all CPUs in total and of every single one,
+// which the LoadMaster reports as cpu0, cpu1 and so on.
+type cpuStats struct {
+	Total cpuUsage   `xml:"total"`
+	Cores []cpuUsage `xml:",any"`
+}
+
+func (s *cpuStats) UnmarshalJSON(b []byte) error {
+	var cpus map[string]cpuUsage
+	if err := json.Unmarshal(b, &cpus); err != nil {
+		return err
+	}
+	for name, usage := range cpus {
+		if name == "total" {
+			s.Total = usage
+		} else if strings.HasPrefix(name, "cpu") {
+			s.Cores = append(s.Cores, usage)
+		}
+	}
+	return nil
+}
+
+type memoryStats struct {
+	Used        int `json:"memused" xml:"memused"`
+	Free        int `json:"memfree" xml:"memfree"`
+	UsedPercent int `json:"percentmemused" xml:"percentmemused"`
+}
+
+func (c *Client) getStats() (*stats, error) {
+	cmd := "stats"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var s stats
+	if err := c.sendCommand(cmd, payload, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
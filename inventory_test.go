@@ -0,0 +1,111 @@
+package lmclient
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetLicenseInfo(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{"licenseinfo": "licenseinfo"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			l, err := client.GetLicenseInfo()
+			ok(t, err)
+			equals(t, "VLM-1000", l.LicenseType)
+			equals(t, "unlimited", l.LicensedUntil)
+			equals(t, "2025-01-15", l.SupportUntil)
+			equals(t, 1000, l.Bandwidth)
+			equals(t, true, l.Activated())
+		})
+	}
+}
+
+func TestGetHardwareInfo(t *testing.T) {
+	testCases := []struct {
+		stats  string
+		diskGB float64
+	}{
+		{"stats", 12.5},
+		{"statsnested", 16},
+	}
+	for _, tc := range testCases {
+		for _, apiversion := range []int{2, 1} {
+			t.Run(fmt.Sprintf("%s_apiversion_%d", tc.stats, apiversion), func(t *testing.T) {
+				var cmds, set []string
+				params := newParamHandler(t, apiversion, map[string]string{"serialnumber": "1234567", "model": "VLM-1000"}, &set)
+				commands := newCommandHandler(t, apiversion, map[string]string{"stats": tc.stats, "showiface": "showiface"}, &cmds)
+				server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					body, err := ioutil.ReadAll(req.Body)
+					ok(t, err)
+					req.Body = ioutil.NopCloser(bytes.NewReader(body))
+					if req.URL.Path == "/access/get" || strings.Contains(string(body), `"cmd":"get"`) {
+						params(rw, req)
+						return
+					}
+					commands(rw, req)
+				}))
+				defer server.Close()
+				client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+				hw, err := client.GetHardwareInfo()
+				ok(t, err)
+				equals(t, &HardwareInfo{
+					Model:        "VLM-1000",
+					Serial:       "1234567",
+					CPUs:         2,
+					MemoryMB:     4096,
+					DiskGB:       tc.diskGB,
+					MACAddresses: []string{"00:50:56:8a:1b:01", "00:50:56:8a:1b:02"},
+				}, hw)
+			})
+		}
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	params := map[string]string{"version": "7.2.54.0.20870.RELEASE", "uptime": "60"}
+	var set []string
+	server := newParamServer(t, 2, params, &set)
+	defer server.Close()
+	client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: 2}
+
+	version, err := client.GetVersion()
+	ok(t, err)
+	equals(t, "7.2.54.0.20870.RELEASE", version)
+
+	// Read from the appliance information cached by the first call
+	server.Close()
+	version, err = client.GetVersion()
+	ok(t, err)
+	equals(t, "7.2.54.0.20870.RELEASE", version)
+}
+
+func TestGetSystemUsage(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{"stats": "stats"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			u, err := client.GetSystemUsage()
+			ok(t, err)
+			equals(t, 4, u.CPUUser)
+			equals(t, 93, u.CPUIdle)
+			equals(t, 25, u.MemoryUsedPercent)
+			equals(t, []DiskUsage{
+				{Name: "/var/log", TotalGB: 12.5, UsedGB: 2.5, UsedPercent: 20},
+				{Name: "/var/log/userlog", TotalGB: 4, UsedGB: 1, UsedPercent: 25},
+			}, u.Disks)
+		})
+	}
+}
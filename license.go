@@ -0,0 +1,98 @@
+package lmclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+)
+
+type accessKey struct {
+	XMLName   xml.Name `xml:"Response"`
+	AccessKey string   `xml:"Success>Data>AccessKey"`
+}
+
+// GetAccessKey returns the access key identifying the LoadMaster, which
+// the offline activation flow needs to generate a license blob.
+func (c *Client) GetAccessKey() (string, error) {
+	cmd := "accesskey"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var key accessKey
+	if err := c.sendCommand(cmd, payload, &key); err != nil {
+		return "", err
+	}
+	if key.AccessKey == "" {
+		return "", errors.New("No access key in response")
+	}
+	return key.AccessKey, nil
+}
+
+// ActivateLicenseOffline installs a license blob generated for the access
+// key of the LoadMaster and returns the resulting license.
+func (c *Client) ActivateLicenseOffline(ctx context.Context, blob []byte) (*LicenseInfo, error) {
+	if len(blob) == 0 {
+		return nil, errors.New("License blob must not be empty")
+	}
+
+	cmd := "license"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	req, err := c.newUploadRequest(ctx, cmd, payload, bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(req)
+	if err != nil {
+		return nil, c.responseError(resp, err)
+	}
+
+	var ar ApiResponse
+	if err := c.decodeResponse(resp, &ar); err != nil {
+		return nil, err
+	}
+	if ar.Status != "ok" {
+		return nil, c.responseError(resp, errors.New("License activation failed"))
+	}
+	return c.GetLicenseInfo()
+}
+
+// ActivateLicenseOnline licenses the LoadMaster through the vendor license
+// server, using the KEMP ID and password of the account and optionally the
+// order ID of the license to use. It returns the resulting license. On API
+// version 1 the password is sent as query parameter, see PasswordInQuery.
+func (c *Client) ActivateLicenseOnline(kempID string, password string, orderID string) (*LicenseInfo, error) {
+	if kempID == "" || password == "" {
+		return nil, errors.New("KEMP ID and password must not be empty")
+	}
+	if err := c.checkPasswordInQuery(); err != nil {
+		return nil, err
+	}
+
+	cmd := "alsilicense"
+	payload := struct {
+		CMD      string `json:"cmd" qs:"-"`
+		KempID   string `json:"kempid" qs:"kempid"`
+		Password string `json:"password" qs:"password"`
+		OrderID  string `json:"orderid,omitempty" qs:"orderid,omitempty"`
+	}{
+		CMD:      cmd,
+		KempID:   kempID,
+		Password: password,
+		OrderID:  orderID,
+	}
+
+	if _, err := c.sendApiCommand(cmd, payload); err != nil {
+		return nil, err
+	}
+	return c.GetLicenseInfo()
+}
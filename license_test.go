@@ -0,0 +1,134 @@
+package lmclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newLicenseServer fakes the licensing commands of a LoadMaster. It accepts
+// the license blob "blob" and the KEMP ID "user@example.com" with password
+// "secret", and answers licenseinfo and accesskey from the test data.
+func newLicenseServer(t *testing.T, apiversion int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var cmd string
+		var blob []byte
+		params := map[string]string{}
+		ext := ".json"
+		if apiversion == 1 {
+			cmd = strings.TrimPrefix(req.URL.Path, "/access/")
+			for k, v := range req.URL.Query() {
+				params[k] = v[0]
+			}
+			var err error
+			blob, err = ioutil.ReadAll(req.Body)
+			ok(t, err)
+			ext = ".xml"
+		} else {
+			body, err := ioutil.ReadAll(req.Body)
+			ok(t, err)
+			var payload map[string]string
+			ok(t, json.Unmarshal(body, &payload))
+			cmd = payload["cmd"]
+			params = payload
+			blob, err = base64.StdEncoding.DecodeString(payload["data"])
+			ok(t, err)
+		}
+
+		accepted := true
+		switch cmd {
+		case "license":
+			accepted = string(blob) == "blob"
+		case "alsilicense":
+			accepted = params["kempid"] == "user@example.com" && params["password"] == "secret"
+		}
+
+		var content []byte
+		switch {
+		case !accepted && apiversion == 1:
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			content = []byte(`<?xml version="1.0" encoding="ISO-8859-1"?>
+<Response stat="422" code="fail">
+<Error>License activation failed</Error>
+</Response>`)
+		case !accepted:
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			content = []byte(`{ "code": 422, "message": "License activation failed", "status": "fail" }`)
+		case cmd == "license" || cmd == "alsilicense":
+			var err error
+			content, err = ioutil.ReadFile("test_data/ok" + ext)
+			ok(t, err)
+		default:
+			var err error
+			content, err = ioutil.ReadFile("test_data/" + cmd + ext)
+			ok(t, err)
+		}
+		_, err := rw.Write(content)
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+}
+
+func TestGetAccessKey(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			server := newLicenseServer(t, apiversion)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			key, err := client.GetAccessKey()
+			ok(t, err)
+			equals(t, "AB12-CD34-EF56-7890", key)
+		})
+	}
+}
+
+func TestActivateLicenseOffline(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			server := newLicenseServer(t, apiversion)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			l, err := client.ActivateLicenseOffline(context.Background(), []byte("blob"))
+			ok(t, err)
+			equals(t, "VLM-1000", l.LicenseType)
+
+			_, err = client.ActivateLicenseOffline(context.Background(), []byte("forged"))
+			equals(t, "Code: 422 Message: License activation failed", err.Error())
+		})
+	}
+}
+
+func TestActivateLicenseOnline(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			server := newLicenseServer(t, apiversion)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+			if apiversion == 1 {
+				_, err := client.ActivateLicenseOnline("user@example.com", "secret", "")
+				equals(t, "Password would be sent as query parameter with API version 1, set PasswordInQuery to allow it", err.Error())
+				client.PasswordInQuery = true
+			}
+
+			l, err := client.ActivateLicenseOnline("user@example.com", "secret", "")
+			ok(t, err)
+			equals(t, true, l.Activated())
+
+			_, err = client.ActivateLicenseOnline("user@example.com", "wrong", "1234")
+			equals(t, "Code: 422 Message: License activation failed", err.Error())
+			equals(t, false, strings.Contains(err.Error(), "wrong"))
+
+			_, err = client.ActivateLicenseOnline("", "secret", "")
+			equals(t, "KEMP ID and password must not be empty", err.Error())
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package lmclient
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// VsLimits are the traffic limits of a Virtual Service. Zero means
+// unlimited. Like VsWAF they are filled in by GetVs and only sent by
+// CreateVs and ModifyVs when set.
+type VsLimits struct {
+	// Bandwidth is in kbit/s.
+	Bandwidth           int `xml:"Success>Data>Bandwidth"`
+	ConnsPerSecLimit    int `xml:"Success>Data>ConnsPerSecLimit"`
+	RequestsPerSecLimit int `xml:"Success>Data>RequestsPerSecLimit"`
+	MaxConnsLimit       int `xml:"Success>Data>MaxConnsLimit"`
+}
+
+// limitsPayload is the part of the addvs and modvs payloads setting the
+// limits of a Virtual Service.
+type limitsPayload struct {
+	Bandwidth           int `json:"Bandwidth" qs:"Bandwidth"`
+	ConnsPerSecLimit    int `json:"ConnsPerSecLimit" qs:"ConnsPerSecLimit"`
+	RequestsPerSecLimit int `json:"RequestsPerSecLimit" qs:"RequestsPerSecLimit"`
+	MaxConnsLimit       int `json:"MaxConnsLimit" qs:"MaxConnsLimit"`
+}
+
+func (l *VsLimits) payload() *limitsPayload {
+	if l == nil {
+		return nil
+	}
+	return &limitsPayload{
+		Bandwidth:           l.Bandwidth,
+		ConnsPerSecLimit:    l.ConnsPerSecLimit,
+		RequestsPerSecLimit: l.RequestsPerSecLimit,
+		MaxConnsLimit:       l.MaxConnsLimit,
+	}
+}
+
+// ClientLimits are the limits applied to each client address across all
+// Virtual Services, unless a ClientLimitException for the address exists.
+// Zero means unlimited.
+type ClientLimits struct {
+	ConnsPerSec    int
+	RequestsPerSec int
+	MaxConns       int
+	// Bandwidth is in kbit/s.
+	Bandwidth int
+}
+
+func (c *Client) GetClientLimits() (*ClientLimits, error) {
+	names := []string{"ClientCPSLimit", "ClientRPSLimit", "ClientMaxConnsLimit", "ClientBandwidthLimit"}
+	p, err := c.getParams(names)
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]int, len(names))
+	for i, name := range names {
+		if p[name] == "" {
+			continue
+		}
+		limits[i], err = strconv.Atoi(p[name])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value %s for parameter %s", p[name], name)
+		}
+	}
+	return &ClientLimits{
+		ConnsPerSec:    limits[0],
+		RequestsPerSec: limits[1],
+		MaxConns:       limits[2],
+		Bandwidth:      limits[3],
+	}, nil
+}
+
+// SetClientLimits makes the per client limits of the LoadMaster match l and
+// reports whether anything had to be changed.
+func (c *Client) SetClientLimits(l *ClientLimits) (bool, error) {
+	return c.convergeParams(map[string]string{
+		"ClientCPSLimit":       strconv.Itoa(l.ConnsPerSec),
+		"ClientRPSLimit":       strconv.Itoa(l.RequestsPerSec),
+		"ClientMaxConnsLimit":  strconv.Itoa(l.MaxConns),
+		"ClientBandwidthLimit": strconv.Itoa(l.Bandwidth),
+	})
+}
+
+// ClientLimitType selects which of the ClientLimits an exception overrides.
+type ClientLimitType string
+
+const (
+	ClientLimitConnsPerSec    ClientLimitType = "cps"
+	ClientLimitRequestsPerSec ClientLimitType = "rps"
+	ClientLimitMaxConns       ClientLimitType = "maxc"
+	ClientLimitBandwidth      ClientLimitType = "bandwidth"
+)
+
+type ClientLimitExceptions struct {
+	XMLName xml.Name               `xml:"Response"`
+	Client  []ClientLimitException `xml:"Success>Data>Client"`
+}
+
+// ClientLimitException overrides a client limit for an address or CIDR
+// network. A Limit of zero exempts it from the limit.
+type ClientLimitException struct {
+	Addr  string
+	Limit int
+}
+
+func (c *Client) ListClientLimitExceptions(typ ClientLimitType) ([]ClientLimitException, error) {
+	cmd := "afeclient" + string(typ) + "limitlist"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var exceptions ClientLimitExceptions
+	if err := c.sendCommand(cmd, payload, &exceptions); err != nil {
+		return nil, err
+	}
+	return exceptions.Client, nil
+}
+
+// AddClientLimitException sets the limit of type typ for the address or
+// CIDR network addr, replacing an existing exception for it.
+func (c *Client) AddClientLimitException(typ ClientLimitType, addr string, limit int) (*ApiResponse, error) {
+	addr, err := NormalizeACLAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("Invalid client limit: %d", limit)
+	}
+
+	cmd := "afeclient" + string(typ) + "limitadd"
+	payload := struct {
+		CMD   string `json:"cmd" qs:"-"`
+		Addr  string `json:"ip" qs:"ip"`
+		Limit int    `json:"limit" qs:"limit"`
+	}{
+		CMD:   cmd,
+		Addr:  addr,
+		Limit: limit,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+func (c *Client) DeleteClientLimitException(typ ClientLimitType, addr string) (*ApiResponse, error) {
+	addr, err := NormalizeACLAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := "afeclient" + string(typ) + "limitdel"
+	payload := struct {
+		CMD  string `json:"cmd" qs:"-"`
+		Addr string `json:"ip" qs:"ip"`
+	}{
+		CMD:  cmd,
+		Addr: addr,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
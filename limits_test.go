@@ -0,0 +1,115 @@
+package lmclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestModifyVsLimits(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+		datafile   string
+	}{
+		{2, "/accessv2", "test_data/modvs.json"},
+		{1, "/access/modvs?Bandwidth=10000&ConnsPerSecLimit=200&Enable=Y&MaxConnsLimit=5000&RequestsPerSecLimit=0&forcel4=0&forcel7=1&port=&vs=1&vsaddress=", "test_data/modvs.xml"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			content, err := ioutil.ReadFile(tc.datafile)
+			ok(t, err)
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				equals(t, req.URL.String(), tc.url)
+				_, err := rw.Write([]byte(content))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: tc.apiversion}
+
+			_, err = client.ModifyVs(&Vs{
+				Index:  1,
+				Enable: true,
+				Limits: &VsLimits{
+					Bandwidth:        10000,
+					ConnsPerSecLimit: 200,
+					MaxConnsLimit:    5000,
+				},
+			})
+			ok(t, err)
+		})
+	}
+}
+
+func TestGetVsLimits(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{"showvs": "showvs"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			vs, err := client.GetVs(1)
+			ok(t, err)
+			equals(t, &VsLimits{}, vs.Limits)
+		})
+	}
+}
+
+func TestSetClientLimits(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			params := map[string]string{
+				"ClientCPSLimit":       "100",
+				"ClientRPSLimit":       "0",
+				"ClientMaxConnsLimit":  "0",
+				"ClientBandwidthLimit": "0",
+			}
+			var set []string
+			server := newParamServer(t, apiversion, params, &set)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			limits, err := client.GetClientLimits()
+			ok(t, err)
+			equals(t, &ClientLimits{ConnsPerSec: 100}, limits)
+
+			limits.ConnsPerSec = 20
+			limits.RequestsPerSec = 50
+			changed, err := client.SetClientLimits(limits)
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, []string{"ClientCPSLimit", "ClientRPSLimit"}, set)
+			equals(t, "20", params["ClientCPSLimit"])
+		})
+	}
+}
+
+func TestClientLimitExceptions(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{
+				"afeclientcpslimitlist": "afeclientcpslimitlist",
+				"afeclientcpslimitadd":  "ok",
+			}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			exceptions, err := client.ListClientLimitExceptions(ClientLimitConnsPerSec)
+			ok(t, err)
+			equals(t, []ClientLimitException{{Addr: "10.0.0.0/8"}, {Addr: "192.168.7.1", Limit: 500}}, exceptions)
+
+			_, err = client.AddClientLimitException(ClientLimitConnsPerSec, "172.16.0.0/12", 0)
+			ok(t, err)
+			equals(t, []string{"afeclientcpslimitlist", "afeclientcpslimitadd"}, cmds)
+
+			_, err = client.AddClientLimitException(ClientLimitConnsPerSec, "172.16.0.0/33", 0)
+			equals(t, "Invalid ACL address: 172.16.0.0/33", err.Error())
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package lmclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Logger receives the log messages of a client. Its method set matches
+// *slog.Logger, so one can be used directly: args are alternating keys and
+// values.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Warn(msg string, args ...any)
+}
+
+// stdLogger is used when no Logger is set. It writes warnings to the
+// standard logger and drops debug messages.
+type stdLogger struct{}
+
+func (stdLogger) Debug(msg string, args ...any) {}
+
+func (stdLogger) Warn(msg string, args ...any) {
+	log.Printf("[WARN] %s%s", msg, formatArgs(args))
+}
+
+func formatArgs(args []any) string {
+	var b strings.Builder
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", args[i])
+		}
+	}
+	return b.String()
+}
+
+func (c *Client) logger() Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return stdLogger{}
+}
+
+type commandKey struct{}
+
+// Command returns the LoadMaster command a request built by the client
+// sends, for use in BeforeRequest and AfterResponse hooks.
+func Command(req *http.Request) string {
+	cmd, _ := req.Context().Value(commandKey{}).(string)
+	return cmd
+}
+
+// do sends a request, running the hooks, limiter and logging around it.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.BeforeRequest != nil {
+		req = c.BeforeRequest(req)
+	}
+
+	release, err := c.limit(req)
+	if err != nil {
+		// Not sent, so the body is not closed by the transport
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		c.logger().Debug("LoadMaster request failed", "command", Command(req), "version", c.Version, "duration", time.Duration(0), "error", err)
+		if c.AfterResponse != nil {
+			c.AfterResponse(req, nil, err, 0)
+		}
+		return nil, err
+	}
+
+	start := time.Now()
+	res, err := c.HttpClient.Do(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		release()
+		err = c.redactError(err)
+		c.logger().Debug("LoadMaster request failed", "command", Command(req), "version", c.Version, "duration", elapsed, "error", err)
+	} else {
+		res.Body = &releaseOnClose{ReadCloser: res.Body, release: release}
+		c.logger().Debug("LoadMaster request", "command", Command(req), "version", c.Version, "duration", elapsed, "status", res.StatusCode)
+	}
+
+	if c.AfterResponse != nil {
+		c.AfterResponse(req, res, err, elapsed)
+	}
+	return res, err
+}
+
+func withCommand(ctx context.Context, cmd string) context.Context {
+	return context.WithValue(ctx, commandKey{}, cmd)
+}
@@ -0,0 +1,94 @@
+package lmclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	msgs []string
+}
+
+func (l *recordingLogger) Debug(msg string, args ...any) {
+	l.msgs = append(l.msgs, "DEBUG "+msg+formatArgs(args))
+}
+
+func (l *recordingLogger) Warn(msg string, args ...any) {
+	l.msgs = append(l.msgs, "WARN "+msg+formatArgs(args))
+}
+
+type spanKey struct{}
+
+func TestLoggingAndHooks(t *testing.T) {
+	content, err := ioutil.ReadFile("test_data/listvs.json")
+	ok(t, err)
+	// Start a local HTTP server
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, err := rw.Write(content)
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+
+	defer server.Close()
+	logger := &recordingLogger{}
+	var hooks []string
+	client := Client{HttpClient: server.Client(), ApiKey: "s3cr3t", RestUrl: server.URL, Version: 2, Logger: logger}
+	client.BeforeRequest = func(req *http.Request) *http.Request {
+		hooks = append(hooks, "before "+Command(req))
+		return req.WithContext(context.WithValue(req.Context(), spanKey{}, "span"))
+	}
+	client.AfterResponse = func(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+		ok(t, err)
+		hooks = append(hooks, fmt.Sprintf("after %s %v %d", Command(req), req.Context().Value(spanKey{}), res.StatusCode))
+	}
+
+	_, err = client.GetAllVs()
+	ok(t, err)
+
+	equals(t, []string{"before listvs", "after listvs span 200"}, hooks)
+	equals(t, 1, len(logger.msgs))
+	equals(t, true, strings.HasPrefix(logger.msgs[0], "DEBUG LoadMaster request command=listvs version=2 duration="))
+	equals(t, true, strings.HasSuffix(logger.msgs[0], " status=200"))
+	equals(t, false, strings.Contains(logger.msgs[0], "s3cr3t"))
+}
+
+func TestHooksOnLimiterError(t *testing.T) {
+	// Start a local HTTP server never reached
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		t.Errorf("unexpected request %s", req.URL)
+	}))
+
+	defer server.Close()
+	limiter := NewLimiter(0, 0, 1)
+	// Hold the only slot, so the upload waits until its context is done
+	release, err := limiter.acquire(context.Background(), "listvs")
+	ok(t, err)
+	defer release()
+
+	var hooks []string
+	client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: 2, Limiter: limiter}
+	client.BeforeRequest = func(req *http.Request) *http.Request {
+		hooks = append(hooks, "before "+Command(req))
+		return req
+	}
+	client.AfterResponse = func(req *http.Request, res *http.Response, err error, elapsed time.Duration) {
+		hooks = append(hooks, fmt.Sprintf("after %s %v %v", Command(req), res == nil, err))
+		// The body of the upload must be closed, stopping its writer
+		_, rerr := req.Body.Read(make([]byte, 1))
+		equals(t, io.ErrClosedPipe, rerr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.UploadTemplate(ctx, strings.NewReader("template"))
+	equals(t, context.Canceled, err)
+	equals(t, []string{"before uploadtemplate", "after uploadtemplate true context canceled"}, hooks)
+}
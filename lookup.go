@@ -0,0 +1,107 @@
+package lmclient
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is matched by the errors of lookups finding nothing.
+	ErrNotFound = errors.New("Not found")
+	// ErrAmbiguous is matched by an *AmbiguousError.
+	ErrAmbiguous = errors.New("Ambiguous name")
+)
+
+// notFoundError is a lookup error matching ErrNotFound.
+type notFoundError string
+
+func (e notFoundError) Error() string {
+	return string(e)
+}
+
+func (e notFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func notFound(format string, args ...interface{}) error {
+	return notFoundError(fmt.Sprintf(format, args...))
+}
+
+// AmbiguousError is returned by name lookups when several Virtual Services
+// share the nickname, as the LoadMaster does not enforce unique nicknames.
+type AmbiguousError struct {
+	Name    string
+	Indexes []int
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("Virtual Service name %s is ambiguous, matching indexes %v", e.Name, e.Indexes)
+}
+
+func (e *AmbiguousError) Is(target error) bool {
+	return target == ErrAmbiguous
+}
+
+// vsIndexesByName returns the indexes of all Virtual Services by nickname.
+func vsIndexesByName(vss []VsListed) map[string][]int {
+	names := make(map[string][]int, len(vss))
+	for _, vs := range vss {
+		names[vs.NickName] = append(names[vs.NickName], vs.Index)
+	}
+	return names
+}
+
+// LookupVsIndex returns the index of the only Virtual Service with the
+// nickname. The error matches ErrNotFound if there is none and is an
+// *AmbiguousError if there are several. With CacheNames set, the list of
+// Virtual Services is only fetched once until InvalidateNameCache is
+// called.
+func (c *Client) LookupVsIndex(nickname string) (int, error) {
+	names, err := c.vsNames()
+	if err != nil {
+		return 0, err
+	}
+
+	indexes := names[nickname]
+	switch len(indexes) {
+	case 0:
+		return 0, notFound("Virtual Service with name %s not found", nickname)
+	case 1:
+		return indexes[0], nil
+	}
+	return 0, &AmbiguousError{Name: nickname, Indexes: append([]int(nil), indexes...)}
+}
+
+func (c *Client) vsNames() (map[string][]int, error) {
+	c.mu.RLock()
+	names, gen := c.names, c.namesGen
+	c.mu.RUnlock()
+	if c.CacheNames && names != nil {
+		return names, nil
+	}
+
+	vss, err := c.GetAllVs()
+	if err != nil {
+		return nil, err
+	}
+	names = vsIndexesByName(vss)
+	if c.CacheNames {
+		c.mu.Lock()
+		// A change made while listing may not be in the list
+		if c.namesGen == gen {
+			c.names = names
+		}
+		c.mu.Unlock()
+	}
+	return names, nil
+}
+
+// InvalidateNameCache drops the names cached for LookupVsIndex. The client
+// does so itself once it added, modified or deleted a Virtual Service, but
+// changes made by others are only seen after calling it.
+func (c *Client) InvalidateNameCache() {
+	c.mu.Lock()
+	c.names = nil
+	c.namesGen++
+	c.mu.Unlock()
+}
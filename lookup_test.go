@@ -0,0 +1,101 @@
+package lmclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestLookupVsIndex(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{
+				"listvs": "listvsdup",
+				"delvs":  "delvs",
+			}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion, CacheNames: true}
+
+			index, err := client.LookupVsIndex("bar")
+			ok(t, err)
+			equals(t, 3, index)
+
+			_, err = client.LookupVsIndex("foo")
+			equals(t, true, errors.Is(err, ErrAmbiguous))
+			var ambiguous *AmbiguousError
+			equals(t, true, errors.As(err, &ambiguous))
+			equals(t, []int{1, 2}, ambiguous.Indexes)
+
+			_, err = client.LookupVsIndex("missing")
+			equals(t, true, errors.Is(err, ErrNotFound))
+			equals(t, "Virtual Service with name missing not found", err.Error())
+			equals(t, []string{"listvs"}, cmds)
+
+			_, err = client.DeleteVs(3)
+			ok(t, err)
+			_, err = client.LookupVsIndex("bar")
+			ok(t, err)
+			client.InvalidateNameCache()
+			_, err = client.LookupVsIndex("bar")
+			ok(t, err)
+			equals(t, []string{"listvs", "delvs", "listvs", "listvs"}, cmds)
+		})
+	}
+}
+
+func TestNameCacheInvalidatedDuringListing(t *testing.T) {
+	var cmds []string
+	handler := newCommandHandler(t, 2, map[string]string{"listvs": "listvsdup"}, &cmds)
+	started, proceed := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	// Start a local HTTP server holding back the first listing
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		once.Do(func() {
+			close(started)
+			<-proceed
+		})
+		handler(rw, req)
+	}))
+	defer server.Close()
+	client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: 2, CacheNames: true}
+
+	done := make(chan error)
+	go func() {
+		_, err := client.LookupVsIndex("bar")
+		done <- err
+	}()
+	<-started
+	// A change made while the listing is running
+	client.InvalidateNameCache()
+	close(proceed)
+	ok(t, <-done)
+
+	_, err := client.LookupVsIndex("bar")
+	ok(t, err)
+	equals(t, []string{"listvs", "listvs"}, cmds)
+}
+
+func TestGetVsByNameAmbiguous(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{"listvs": "listvsdup"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			vs, err := client.GetVsByName("bar")
+			ok(t, err)
+			equals(t, 3, vs.Index)
+
+			_, err = client.GetVsByName("foo")
+			equals(t, "Virtual Service name foo is ambiguous, matching indexes [1 2]", err.Error())
+
+			_, err = client.GetVsByName("missing")
+			equals(t, true, errors.Is(err, ErrNotFound))
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package lmclient
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+type Interfaces struct {
+	XMLName   xml.Name    `xml:"Response"`
+	Interface []Interface `xml:"Success>Data>Interface"`
+}
+
+type Interface struct {
+	XMLName       xml.Name `xml:"Interface"`
+	Id            int      `xml:"Id"`
+	IPAddress     string   `xml:"IPAddress"`
+	MACAddress    string   `xml:"MACAddress"`
+	Mtu           int      `xml:"Mtu"`
+	InterfaceType string   `xml:"InterfaceType"`
+	VlanId        int      `xml:"VlanId"`
+	BondId        int      `xml:"BondId"`
+	GeoTraffic    bool     `xml:"GeoTraffic"`
+	DefaultIface  bool     `json:"DefaultInterface" xml:"DefaultInterface"`
+}
+
+type Routes struct {
+	XMLName xml.Name `xml:"Response"`
+	Route   []Route  `xml:"Success>Data>Route"`
+}
+
+type Route struct {
+	XMLName     xml.Name `xml:"Route"`
+	Destination string   `xml:"Destination"`
+	Gateway     string   `xml:"Gateway"`
+}
+
+func (c *Client) GetAllInterfaces() ([]Interface, error) {
+	cmd := "showiface"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var ifaces Interfaces
+	if err := c.sendCommand(cmd, payload, &ifaces); err != nil {
+		return nil, err
+	}
+	return ifaces.Interface, nil
+}
+
+func (c *Client) GetInterface(id int) (*Interface, error) {
+	cmd := "showiface"
+	payload := struct {
+		CMD   string `json:"cmd" qs:"-"`
+		Iface int    `json:"iface" qs:"iface"`
+	}{
+		CMD:   cmd,
+		Iface: id,
+	}
+
+	var ifaces Interfaces
+	if err := c.sendCommand(cmd, payload, &ifaces); err != nil {
+		return nil, err
+	}
+	if len(ifaces.Interface) == 0 {
+		return nil, notFound("Interface %d not found", id)
+	}
+	return &ifaces.Interface[0], nil
+}
+
+// ModifyInterface sets the address and MTU of an interface. IPAddress is
+// given in CIDR notation, e.g. 10.0.0.2/24.
+func (c *Client) ModifyInterface(i *Interface) (*ApiResponse, error) {
+	cmd := "modiface"
+	payload := struct {
+		CMD       string `json:"cmd" qs:"-"`
+		Iface     int    `json:"iface" qs:"iface"`
+		IPAddress string `json:"addr,omitempty" qs:"addr,omitempty"`
+		Mtu       int    `json:"mtu,omitempty" qs:"mtu,omitempty"`
+	}{
+		CMD:       cmd,
+		Iface:     i.Id,
+		IPAddress: i.IPAddress,
+		Mtu:       i.Mtu,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+// AddVlan creates a VLAN with the given tag on top of interface iface and
+// returns the resulting interface.
+func (c *Client) AddVlan(iface int, vlanid int) (*Interface, error) {
+	cmd := "addvlan"
+	payload := struct {
+		CMD    string `json:"cmd" qs:"-"`
+		Iface  int    `json:"iface" qs:"iface"`
+		VlanId int    `json:"vlanid" qs:"vlanid"`
+	}{
+		CMD:    cmd,
+		Iface:  iface,
+		VlanId: vlanid,
+	}
+
+	var ifaces Interfaces
+	if err := c.sendCommand(cmd, payload, &ifaces); err != nil {
+		return nil, err
+	}
+	if len(ifaces.Interface) == 0 {
+		return nil, fmt.Errorf("VLAN %d on interface %d missing in response", vlanid, iface)
+	}
+	return &ifaces.Interface[0], nil
+}
+
+// DeleteVlan removes the VLAN interface iface.
+func (c *Client) DeleteVlan(iface int) (*ApiResponse, error) {
+	return c.ifaceCommand("delvlan", iface)
+}
+
+// CreateBond turns interface iface into a bond interface.
+func (c *Client) CreateBond(iface int) (*ApiResponse, error) {
+	return c.ifaceCommand("createbond", iface)
+}
+
+// DeleteBond dissolves the bond interface iface.
+func (c *Client) DeleteBond(iface int) (*ApiResponse, error) {
+	return c.ifaceCommand("unbond", iface)
+}
+
+// AddBondMember adds interface iface to the bond interface bond.
+func (c *Client) AddBondMember(bond int, iface int) (*ApiResponse, error) {
+	return c.bondCommand("addbond", bond, iface)
+}
+
+// DeleteBondMember removes interface iface from the bond interface bond.
+func (c *Client) DeleteBondMember(bond int, iface int) (*ApiResponse, error) {
+	return c.bondCommand("delbond", bond, iface)
+}
+
+func (c *Client) ifaceCommand(cmd string, iface int) (*ApiResponse, error) {
+	payload := struct {
+		CMD   string `json:"cmd" qs:"-"`
+		Iface int    `json:"iface" qs:"iface"`
+	}{
+		CMD:   cmd,
+		Iface: iface,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+func (c *Client) bondCommand(cmd string, bond int, iface int) (*ApiResponse, error) {
+	payload := struct {
+		CMD   string `json:"cmd" qs:"-"`
+		Bond  int    `json:"bond" qs:"bond"`
+		Iface int    `json:"iface" qs:"iface"`
+	}{
+		CMD:   cmd,
+		Bond:  bond,
+		Iface: iface,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+func (c *Client) GetAllRoutes() ([]Route, error) {
+	cmd := "showroute"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var routes Routes
+	if err := c.sendCommand(cmd, payload, &routes); err != nil {
+		return nil, err
+	}
+	return routes.Route, nil
+}
+
+// AddRoute adds a static route. Destination is given in CIDR notation.
+func (c *Client) AddRoute(r *Route) (*ApiResponse, error) {
+	cmd := "addroute"
+	payload := struct {
+		CMD         string `json:"cmd" qs:"-"`
+		Destination string `json:"dest" qs:"dest"`
+		Gateway     string `json:"gateway" qs:"gateway"`
+	}{
+		CMD:         cmd,
+		Destination: r.Destination,
+		Gateway:     r.Gateway,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+func (c *Client) DeleteRoute(destination string) (*ApiResponse, error) {
+	cmd := "delroute"
+	payload := struct {
+		CMD         string `json:"cmd" qs:"-"`
+		Destination string `json:"dest" qs:"dest"`
+	}{
+		CMD:         cmd,
+		Destination: destination,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+func (c *Client) GetDefaultGateway() (string, error) {
+	return c.getParam("dfltgw")
+}
+
+// SetDefaultGateway sets the IPv4 default gateway if it differs. It reports
+// whether it changed.
+func (c *Client) SetDefaultGateway(gateway string) (bool, error) {
+	return c.convergeParams(map[string]string{"dfltgw": gateway})
+}
@@ -0,0 +1,178 @@
+package lmclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetAllInterfaces(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+		datafile   string
+	}{
+		{2, "/accessv2", "test_data/showiface.json"},
+		{1, "/access/showiface", "test_data/showiface.xml"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			content, err := ioutil.ReadFile(tc.datafile)
+			ok(t, err)
+			// Start a local HTTP server
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// Test request parameters
+				equals(t, req.URL.String(), tc.url)
+				// Send response to be tested
+				_, err := rw.Write([]byte(content))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
+
+			ifaces, err := client.GetAllInterfaces()
+			ok(t, err)
+
+			equals(t, len(ifaces), 2)
+			equals(t, ifaces[0].IPAddress, "192.168.1.10/24")
+			equals(t, ifaces[0].DefaultIface, true)
+			equals(t, ifaces[1].Id, 1)
+			equals(t, ifaces[1].Mtu, 9000)
+			equals(t, ifaces[1].GeoTraffic, false)
+		})
+	}
+}
+
+func TestGetAllRoutes(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+		datafile   string
+	}{
+		{2, "/accessv2", "test_data/showroute.json"},
+		{1, "/access/showroute", "test_data/showroute.xml"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			content, err := ioutil.ReadFile(tc.datafile)
+			ok(t, err)
+			// Start a local HTTP server
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// Test request parameters
+				equals(t, req.URL.String(), tc.url)
+				// Send response to be tested
+				_, err := rw.Write([]byte(content))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
+
+			routes, err := client.GetAllRoutes()
+			ok(t, err)
+
+			equals(t, len(routes), 1)
+			equals(t, routes[0].Destination, "10.20.0.0/16")
+			equals(t, routes[0].Gateway, "10.0.0.1")
+		})
+	}
+}
+
+func TestAddRoute(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+		datafile   string
+	}{
+		{2, "/accessv2", "test_data/addroute.json"},
+		{1, "/access/addroute?dest=10.20.0.0%2F16&gateway=10.0.0.1", "test_data/addroute.xml"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			content, err := ioutil.ReadFile(tc.datafile)
+			ok(t, err)
+			// Start a local HTTP server
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// Test request parameters
+				equals(t, req.URL.String(), tc.url)
+				// Send response to be tested
+				_, err := rw.Write([]byte(content))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
+
+			ar, err := client.AddRoute(&Route{Destination: "10.20.0.0/16", Gateway: "10.0.0.1"})
+			ok(t, err)
+
+			equals(t, ar.Status, "ok")
+		})
+	}
+}
+
+func TestGetDefaultGateway(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+		datafile   string
+	}{
+		{2, "/accessv2", "test_data/getdfltgw.json"},
+		{1, "/access/get?param=dfltgw", "test_data/getdfltgw.xml"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			content, err := ioutil.ReadFile(tc.datafile)
+			ok(t, err)
+			// Start a local HTTP server
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// Test request parameters
+				equals(t, req.URL.String(), tc.url)
+				// Send response to be tested
+				_, err := rw.Write([]byte(content))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
+
+			gw, err := client.GetDefaultGateway()
+			ok(t, err)
+
+			equals(t, gw, "192.168.1.1")
+		})
+	}
+}
+
+func TestSetDefaultGateway(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			params := map[string]string{"dfltgw": "10.0.0.1"}
+			var set []string
+			server := newParamServer(t, apiversion, params, &set)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			changed, err := client.SetDefaultGateway("10.0.0.1")
+			ok(t, err)
+			equals(t, false, changed)
+
+			changed, err = client.SetDefaultGateway("10.0.0.254")
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, []string{"dfltgw"}, set)
+			equals(t, "10.0.0.254", params["dfltgw"])
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package lmclient
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type paramResponse struct {
+	XMLName xml.Name  `xml:"Response"`
+	Data    paramData `xml:"Success>Data"`
+}
+
+type paramData struct {
+	Values []paramValue `xml:",any"`
+}
+
+type paramValue struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+func (c *Client) getParam(name string) (string, error) {
+	cmd := "get"
+	payload := struct {
+		CMD   string `json:"cmd" qs:"-"`
+		Param string `json:"param" qs:"param"`
+	}{
+		CMD:   cmd,
+		Param: name,
+	}
+
+	if c.Version == 1 {
+		var pr paramResponse
+		if err := c.sendCommand(cmd, payload, &pr); err != nil {
+			return "", err
+		}
+		for _, v := range pr.Data.Values {
+			if v.XMLName.Local == name {
+				return v.Value, nil
+			}
+		}
+		return "", fmt.Errorf("Parameter %s missing in response", name)
+	}
+
+	var pr map[string]json.RawMessage
+	if err := c.sendCommand(cmd, payload, &pr); err != nil {
+		return "", err
+	}
+	raw, found := pr[name]
+	if !found {
+		return "", fmt.Errorf("Parameter %s missing in response", name)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	// Numbers and booleans are passed on in their JSON notation
+	return string(raw), nil
+}
+
+func (c *Client) setParam(name string, value string) (*ApiResponse, error) {
+	if p, _ := LookupParam(name); p.Secret {
+		if err := c.checkPasswordInQuery(); err != nil {
+			return nil, err
+		}
+		ar, err := c.setParamValue(name, value)
+		return ar, c.redactError(err, value)
+	}
+	return c.setParamValue(name, value)
+}
+
+func (c *Client) setParamValue(name string, value string) (*ApiResponse, error) {
+	cmd := "set"
+	payload := struct {
+		CMD   string `json:"cmd" qs:"-"`
+		Param string `json:"param" qs:"param"`
+		Value string `json:"value" qs:"value"`
+	}{
+		CMD:   cmd,
+		Param: name,
+		Value: value,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+// getParams fetches several parameters at once, keyed by name.
+func (c *Client) getParams(names []string) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		v, err := c.getParam(name)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = v
+	}
+	return values, nil
+}
+
+// convergeParams sets the parameters in desired whose current value on the
+// LoadMaster differs, in name order. It reports whether anything changed.
+func (c *Client) convergeParams(desired map[string]string) (bool, error) {
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	current, err := c.getParams(names)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for _, name := range names {
+		if sameParamValue(name, current[name], desired[name]) {
+			continue
+		}
+		if _, err := c.setParam(name, desired[name]); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// sameParamValue compares values of the parameter name the way the
+// LoadMaster treats them according to its registered type, ignoring the
+// spacing of lists and the spelling of booleans. Values of unregistered
+// parameters are compared as strings.
+func sameParamValue(name string, a string, b string) bool {
+	p, _ := LookupParam(name)
+	switch p.Type {
+	case ParamBool:
+		ab, aerr := parseBool(a)
+		bb, berr := parseBool(b)
+		return aerr == nil && berr == nil && ab == bb
+	case ParamInt:
+		ai, aerr := strconv.Atoi(strings.TrimSpace(a))
+		bi, berr := strconv.Atoi(strings.TrimSpace(b))
+		if aerr == nil && berr == nil {
+			return ai == bi
+		}
+	case ParamList:
+		return formatList(parseList(a)) == formatList(parseList(b))
+	}
+	return a == b
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "Y"
+	}
+	return "N"
+}
+
+// parseBool accepts the different spellings LoadMasters use for boolean
+// parameters, including the true/false decodeResponse turns Y/N into.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "y", "yes", "true", "1", "on", "enabled":
+		return true, nil
+	case "n", "no", "false", "0", "off", "disabled", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("Invalid boolean value: %s", s)
+}
+
+func formatList(l []string) string {
+	return strings.Join(l, " ")
+}
+
+func parseList(s string) []string {
+	return strings.Fields(s)
+}
+
+// ParamType is the type of the value of an appliance parameter.
+type ParamType int
+
+const (
+	ParamString ParamType = iota
+	ParamBool
+	ParamInt
+	ParamEnum
+	ParamList
+)
+
+func (t ParamType) String() string {
+	switch t {
+	case ParamString:
+		return "string"
+	case ParamBool:
+		return "bool"
+	case ParamInt:
+		return "int"
+	case ParamEnum:
+		return "enum"
+	case ParamList:
+		return "list"
+	}
+	return fmt.Sprintf("ParamType(%d)", int(t))
+}
+
+// ParamSpec describes an appliance parameter accessible through the get
+// and set commands. Values lists the accepted values of enum parameters.
+// Secret parameters, like passwords, are masked in errors and only set on
+// API version 1 if the client allows PasswordInQuery.
+type ParamSpec struct {
+	Name     string
+	Type     ParamType
+	Values   []string
+	ReadOnly bool
+	Secret   bool
+}
+
+var (
+	paramsMu sync.RWMutex
+	params   = map[string]ParamSpec{}
+)
+
+func init() {
+	for _, p := range []ParamSpec{
+		{Name: "hostname", Type: ParamString},
+		{Name: "dfltgw", Type: ParamString},
+		{Name: "dfltgwv6", Type: ParamString},
+		{Name: "namserver", Type: ParamList},
+		{Name: "searchlist", Type: ParamList},
+		{Name: "ntphost", Type: ParamList},
+		{Name: "timezone", Type: ParamString},
+		{Name: "syslogemergency", Type: ParamList},
+		{Name: "syslogcritical", Type: ParamList},
+		{Name: "syslogerror", Type: ParamList},
+		{Name: "syslogwarn", Type: ParamList},
+		{Name: "syslognotice", Type: ParamList},
+		{Name: "sysloginfo", Type: ParamList},
+		{Name: "snmpenable", Type: ParamBool},
+		{Name: "snmpcommunity", Type: ParamString},
+		{Name: "snmpcontact", Type: ParamString},
+		{Name: "snmplocation", Type: ParamString},
+		{Name: "snmpv3enable", Type: ParamBool},
+		{Name: "snmpv3user", Type: ParamString},
+		{Name: "snmpv3userpasswd", Type: ParamString, Secret: true},
+		{Name: "snmptrapenable", Type: ParamBool},
+		{Name: "snmpv1sink", Type: ParamList},
+		{Name: "snmpv2sink", Type: ParamList},
+		{Name: "emailserver", Type: ParamString},
+		{Name: "emailport", Type: ParamInt},
+		{Name: "emailuser", Type: ParamString},
+		{Name: "emailpassword", Type: ParamString, Secret: true},
+		{Name: "emaildomain", Type: ParamString},
+		{Name: "emailinfo", Type: ParamList},
+		{Name: "emailwarn", Type: ParamList},
+		{Name: "emailerror", Type: ParamList},
+		{Name: "emailcritical", Type: ParamList},
+		{Name: "sshaccess", Type: ParamBool},
+		{Name: "sshport", Type: ParamInt},
+		{Name: "wuiport", Type: ParamInt},
+		{Name: "admingw", Type: ParamString},
+		{Name: "enableapi", Type: ParamBool},
+		{Name: "tethering", Type: ParamBool},
+		{Name: "hamode", Type: ParamEnum, Values: []string{"0", "1", "2", "3"}},
+		{Name: "sslrenegotiate", Type: ParamBool},
+		{Name: "backupenable", Type: ParamBool},
+		{Name: "backupmethod", Type: ParamEnum, Values: []string{"ftp", "scp", "sftp"}},
+		{Name: "backuphost", Type: ParamString},
+		{Name: "backuppath", Type: ParamString},
+		{Name: "backuphour", Type: ParamInt},
+		{Name: "backupminute", Type: ParamInt},
+		{Name: "ClientCPSLimit", Type: ParamInt},
+		{Name: "ClientRPSLimit", Type: ParamInt},
+		{Name: "ClientMaxConnsLimit", Type: ParamInt},
+		{Name: "ClientBandwidthLimit", Type: ParamInt},
+		{Name: "version", Type: ParamString, ReadOnly: true},
+		{Name: "serialnumber", Type: ParamString, ReadOnly: true},
+		{Name: "model", Type: ParamString, ReadOnly: true},
+		{Name: "uptime", Type: ParamInt, ReadOnly: true},
+	} {
+		RegisterParam(p)
+	}
+}
+
+// RegisterParam adds a parameter to the registry consulted by GetParam and
+// SetParam, replacing any existing entry with the same name. It is meant for
+// parameters of newer firmware not known to this package.
+func RegisterParam(p ParamSpec) {
+	paramsMu.Lock()
+	defer paramsMu.Unlock()
+	params[p.Name] = p
+}
+
+func LookupParam(name string) (ParamSpec, bool) {
+	paramsMu.RLock()
+	defer paramsMu.RUnlock()
+	p, found := params[name]
+	return p, found
+}
+
+// GetParam returns the value of a registered appliance parameter, as a
+// bool, int, string or []string depending on its type.
+func (c *Client) GetParam(name string) (interface{}, error) {
+	p, found := LookupParam(name)
+	if !found {
+		return nil, fmt.Errorf("Unknown parameter: %s", name)
+	}
+
+	v, err := c.getParam(name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.Type {
+	case ParamBool:
+		return parseBool(v)
+	case ParamInt:
+		if strings.TrimSpace(v) == "" {
+			return 0, nil
+		}
+		return strconv.Atoi(strings.TrimSpace(v))
+	case ParamList:
+		return parseList(v), nil
+	}
+	return v, nil
+}
+
+// SetParam sets a registered appliance parameter. The value must match the
+// parameter type: bool, int, string or []string, with enum parameters taking
+// one of their listed values as string.
+func (c *Client) SetParam(name string, value interface{}) (*ApiResponse, error) {
+	p, found := LookupParam(name)
+	if !found {
+		return nil, fmt.Errorf("Unknown parameter: %s", name)
+	}
+	if p.ReadOnly {
+		return nil, fmt.Errorf("Parameter %s is read-only", name)
+	}
+
+	v, err := p.format(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.setParam(name, v)
+}
+
+func (p ParamSpec) format(value interface{}) (string, error) {
+	switch p.Type {
+	case ParamBool:
+		if b, ok := value.(bool); ok {
+			return formatBool(b), nil
+		}
+	case ParamInt:
+		if i, ok := value.(int); ok {
+			return strconv.Itoa(i), nil
+		}
+	case ParamString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case ParamEnum:
+		if s, ok := value.(string); ok {
+			for _, allowed := range p.Values {
+				if s == allowed {
+					return s, nil
+				}
+			}
+			return "", fmt.Errorf("Invalid value %s for parameter %s, must be one of: %s", s, p.Name, strings.Join(p.Values, ", "))
+		}
+	case ParamList:
+		if l, ok := value.([]string); ok {
+			return formatList(l), nil
+		}
+	}
+	return "", fmt.Errorf("Invalid value %v for %s parameter %s", value, p.Type, p.Name)
+}
@@ -0,0 +1,74 @@
+package lmclient
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGetSetParam(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			params := map[string]string{
+				"sshaccess":    "Y",
+				"sshport":      "22",
+				"ntphost":      "0.pool.ntp.org 1.pool.ntp.org",
+				"backupmethod": "ftp",
+			}
+			var set []string
+			server := newParamServer(t, apiversion, params, &set)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: apiversion}
+
+			v, err := client.GetParam("sshaccess")
+			ok(t, err)
+			equals(t, true, v)
+
+			v, err = client.GetParam("sshport")
+			ok(t, err)
+			equals(t, 22, v)
+
+			v, err = client.GetParam("ntphost")
+			ok(t, err)
+			equals(t, []string{"0.pool.ntp.org", "1.pool.ntp.org"}, v)
+
+			_, err = client.SetParam("sshaccess", false)
+			ok(t, err)
+			equals(t, "N", params["sshaccess"])
+
+			_, err = client.SetParam("backupmethod", "scp")
+			ok(t, err)
+			equals(t, "scp", params["backupmethod"])
+
+			_, err = client.SetParam("sshport", "22")
+			equals(t, "Invalid value 22 for int parameter sshport", err.Error())
+
+			_, err = client.SetParam("backupmethod", "nfs")
+			equals(t, "Invalid value nfs for parameter backupmethod, must be one of: ftp, scp, sftp", err.Error())
+
+			_, err = client.GetParam("nosuchparam")
+			equals(t, "Unknown parameter: nosuchparam", err.Error())
+
+			equals(t, []string{"sshaccess", "backupmethod"}, set)
+		})
+	}
+}
+
+func TestSameParamValue(t *testing.T) {
+	testCases := []struct {
+		name string
+		a    string
+		b    string
+		same bool
+	}{
+		{"snmpenable", "true", "Y", true},
+		{"snmpenable", "0", "", true},
+		{"snmpcontact", "0", "", false},
+		{"snmpcontact", "1", "Y", false},
+		{"snmpcontact", "admin", "admin", true},
+		{"ntphost", "a  b", "a b", true},
+		{"unregistered", "Y", "true", false},
+	}
+	for _, tc := range testCases {
+		equals(t, tc.same, sameParamValue(tc.name, tc.a, tc.b))
+	}
+}
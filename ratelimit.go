@@ -0,0 +1,177 @@
+package lmclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MutatingCommands are the commands a Limiter serialises by default, as
+// the LoadMaster handles them poorly when they are interleaved.
+var MutatingCommands = []string{
+	"addvs", "modvs", "delvs",
+	"addrs", "modrs", "delrs",
+	"set", "modiface", "addvlan", "delvlan", "createbond", "unbond", "addbond", "delbond",
+	"addroute", "delroute",
+	"useraddlocal", "usersetperms", "userchangelocpass", "userdellocal",
+	"addapikey", "delapikey",
+	"restore",
+	"vsaddwafrule", "vsremovewafrule", "addwafcustomrule", "delwafcustomrule", "addwafcustomdata", "delwafcustomdata",
+	"setwafautoupdate", "enablewafautoinstall", "setwafinstalltime", "downloadwafrules", "maninstallwafrules",
+	"adddomain", "moddomain", "deldomain", "addldapendpoint", "modifyldapendpoint", "deleteldapendpoint",
+	"aclcontrol",
+	"afeclientcpslimitadd", "afeclientcpslimitdel", "afeclientrpslimitadd", "afeclientrpslimitdel",
+	"afeclientmaxclimitadd", "afeclientmaxclimitdel", "afeclientbandwidthlimitadd", "afeclientbandwidthlimitdel",
+	"uploadtemplate", "deltemplate",
+	"addprerule", "addrequestrule", "addresponserule", "addbodyrule", "addrsrule",
+	"license", "alsilicense",
+}
+
+// Limiter throttles the requests of one or more clients. It combines a
+// token bucket allowing Rate requests per second with bursts of up to
+// Burst requests, a limit of MaxInFlight concurrent requests and the
+// serialisation of the commands in Serialize, of which only one is sent at
+// a time. Zero values disable the respective limit.
+//
+// Share one Limiter between all clients talking to the same LoadMaster.
+type Limiter struct {
+	Rate        float64
+	Burst       int
+	MaxInFlight int
+	Serialize   map[string]bool
+
+	initOnce sync.Once
+	inFlight chan struct{}
+	serial   chan struct{}
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a limiter serialising MutatingCommands.
+func NewLimiter(rate float64, burst int, maxInFlight int) *Limiter {
+	serialize := make(map[string]bool, len(MutatingCommands))
+	for _, cmd := range MutatingCommands {
+		serialize[cmd] = true
+	}
+	return &Limiter{
+		Rate:        rate,
+		Burst:       burst,
+		MaxInFlight: maxInFlight,
+		Serialize:   serialize,
+	}
+}
+
+func (l *Limiter) init() {
+	if l.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, l.MaxInFlight)
+	}
+	l.serial = make(chan struct{}, 1)
+	l.tokens = float64(l.burst())
+	l.last = time.Now()
+}
+
+func (l *Limiter) burst() int {
+	if l.Burst < 1 {
+		return 1
+	}
+	return l.Burst
+}
+
+// acquire blocks until a request for cmd may be sent and returns the
+// function to call once it is done.
+func (l *Limiter) acquire(ctx context.Context, cmd string) (func(), error) {
+	l.initOnce.Do(l.init)
+
+	var held []chan struct{}
+	release := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			<-held[i]
+		}
+	}
+
+	slots := []chan struct{}{l.inFlight}
+	if l.Serialize[cmd] {
+		slots = []chan struct{}{l.serial, l.inFlight}
+	}
+	for _, slot := range slots {
+		if slot == nil {
+			continue
+		}
+		select {
+		case slot <- struct{}{}:
+			held = append(held, slot)
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	if err := l.wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// wait takes a token from the bucket, waiting for one to become available.
+func (l *Limiter) wait(ctx context.Context) error {
+	if l.Rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.Rate
+	if max := float64(l.burst()); l.tokens > max {
+		l.tokens = max
+	}
+	l.last = now
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.Rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give back the token reserved above
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// releaseOnClose releases a limiter slot once the response body is closed,
+// so responses still being read count as in flight.
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
+
+// limit waits for the limiter of the client, if any, before req is sent.
+func (c *Client) limit(req *http.Request) (func(), error) {
+	if c.Limiter == nil {
+		return func() {}, nil
+	}
+	return c.Limiter.acquire(req.Context(), Command(req))
+}
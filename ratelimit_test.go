@@ -0,0 +1,74 @@
+package lmclient
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiterRate(t *testing.T) {
+	l := NewLimiter(50, 2, 0)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		release, err := l.acquire(context.Background(), "listvs")
+		ok(t, err)
+		release()
+	}
+	// Two requests pass as burst, the other three wait 20ms each
+	elapsed := time.Since(start)
+	equals(t, true, elapsed >= 55*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l = NewLimiter(1, 1, 0)
+	release, err := l.acquire(ctx, "listvs")
+	ok(t, err)
+	release()
+	_, err = l.acquire(ctx, "listvs")
+	equals(t, context.Canceled, err)
+}
+
+func TestLimiterSerializesMutations(t *testing.T) {
+	content, err := ioutil.ReadFile("test_data/delvs.json")
+	ok(t, err)
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	// Start a local HTTP server tracking concurrent requests
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		_, err := rw.Write(content)
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}))
+
+	defer server.Close()
+	client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: 2, Limiter: NewLimiter(0, 0, 4)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := client.DeleteVs(i)
+			ok(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	equals(t, 1, maxInFlight)
+}
@@ -16,6 +16,7 @@ type Rss struct {
 
 type Rs struct {
 	XMLName   xml.Name `xml:"Rs"`
+	Status    string
 	VSIndex   int
 	RsIndex   int
 	Rsi       string
@@ -28,24 +29,21 @@ type Rs struct {
 	Limit     int
 	RateLimit int
 	Follow    int
+	Enable    bool
 	Nrules    int
+	// MatchRules are the content rules assigned to the Real Server.
+	MatchRules []string `xml:"MatchRules>Name"`
 }
 
 func (c *Client) CreateRs(r *Rs) (*Rs, error) {
 	cmd := "addrs"
 	rsa := struct {
-		ApiKey   string `json:"apikey" qs:"apikey"`
-		ApiUser  string `json:"apiuser,omitempty" qs:"-"`
-		ApiPass  string `json:"apipass,omitempty" qs:"-"`
 		CMD      string `json:"cmd" qs:"-"`
 		VSIndex  int    `json:"vs" qs:"vs"`
 		Addr     string `json:"rs,omitempty" qs:"rs"`
 		Port     int    `json:"rsport,omitempty" qs:"rsport"`
 		NonLocal int    `json:"non_local" qs:"non_local"`
 	}{
-		ApiKey:   c.ApiKey,
-		ApiUser:  c.ApiUser,
-		ApiPass:  c.ApiPass,
 		CMD:      cmd,
 		VSIndex:  r.VSIndex,
 		Addr:     r.Addr,
@@ -64,19 +62,8 @@ func (c *Client) CreateRs(r *Rs) (*Rs, error) {
 	}
 
 	var rss Rss
-	if c.Version == 1 {
-		reader := bytes.NewReader(resp)
-		decoder := xml.NewDecoder(reader)
-		decoder.CharsetReader = makeCharsetReader
-		err = decoder.Decode(&rss)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		err = json.Unmarshal(resp, &rss)
-		if err != nil {
-			return nil, err
-		}
+	if err := c.decodeResponse(resp, &rss); err != nil {
+		return nil, err
 	}
 	return &rss.RS[0], nil
 }
@@ -86,16 +73,10 @@ func (c *Client) GetRs(index int, vsindex int) (*Rs, error) {
 	rsa := struct {
 		VSIndex int    `json:"vs" qs:"vs"`
 		CMD     string `json:"cmd" qs:"-"`
-		ApiKey  string `json:"apikey" qs:"apikey"`
-		ApiUser string `json:"apiuser,omitempty" qs:"-"`
-		ApiPass string `json:"apipass,omitempty" qs:"-"`
 		Rsi     string `json:"rs" qs:"rs"`
 	}{
 		VSIndex: vsindex,
 		CMD:     cmd,
-		ApiKey:  c.ApiKey,
-		ApiUser: c.ApiUser,
-		ApiPass: c.ApiPass,
 		Rsi:     "!" + strconv.Itoa(index),
 	}
 	req, err := c.newRequest(cmd, rsa)
@@ -129,19 +110,8 @@ func (c *Client) GetRs(index int, vsindex int) (*Rs, error) {
 	}
 
 	var rss Rss
-	if c.Version == 1 {
-		reader := bytes.NewReader(resp)
-		decoder := xml.NewDecoder(reader)
-		decoder.CharsetReader = makeCharsetReader
-		err = decoder.Decode(&rss)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		err = json.Unmarshal(resp, &rss)
-		if err != nil {
-			return nil, err
-		}
+	if err := c.decodeResponse(resp, &rss); err != nil {
+		return nil, err
 	}
 
 	return &rss.RS[0], nil
@@ -152,16 +122,10 @@ func (c *Client) DeleteRs(index int, vsindex int) (*ApiResponse, error) {
 	rsa := struct {
 		VSIndex int    `json:"vs" qs:"vs"`
 		CMD     string `json:"cmd" qs:"-"`
-		ApiKey  string `json:"apikey" qs:"apikey"`
-		ApiUser string `json:"apiuser,omitempty" qs:"-"`
-		ApiPass string `json:"apipass,omitempty" qs:"-"`
 		Rsi     string `json:"rs" qs:"rs"`
 	}{
 		VSIndex: vsindex,
 		CMD:     cmd,
-		ApiKey:  c.ApiKey,
-		ApiUser: c.ApiUser,
-		ApiPass: c.ApiPass,
 		Rsi:     "!" + strconv.Itoa(index),
 	}
 	req, err := c.newRequest(cmd, rsa)
@@ -199,23 +163,25 @@ func (c *Client) DeleteRs(index int, vsindex int) (*ApiResponse, error) {
 func (c *Client) ModifyRs(r *Rs) (*ApiResponse, error) {
 	cmd := "modrs"
 	rsa := struct {
-		ApiKey   string `json:"apikey" qs:"apikey"`
-		ApiUser  string `json:"apiuser,omitempty" qs:"-"`
-		ApiPass  string `json:"apipass,omitempty" qs:"-"`
-		CMD      string `json:"cmd" qs:"-"`
-		VSIndex  int    `json:"vs" qs:"vs"`
-		Rsi      string `json:"rs" qs:"rs"`
-		NewPort  string `json:"newport" qs:"newport,omitempty"`
-		NonLocal int    `json:"non_local" qs:"non_local"`
+		CMD       string `json:"cmd" qs:"-"`
+		VSIndex   int    `json:"vs" qs:"vs"`
+		Rsi       string `json:"rs" qs:"rs"`
+		NewPort   string `json:"newport" qs:"newport,omitempty"`
+		Weight    int    `json:"weight,omitempty" qs:"weight,omitempty"`
+		Forward   string `json:"forward,omitempty" qs:"forward,omitempty"`
+		Limit     int    `json:"limit,omitempty" qs:"limit,omitempty"`
+		RateLimit int    `json:"ratelimit,omitempty" qs:"ratelimit,omitempty"`
+		NonLocal  int    `json:"non_local" qs:"non_local"`
 	}{
-		ApiKey:   c.ApiKey,
-		ApiUser:  c.ApiUser,
-		ApiPass:  c.ApiPass,
-		CMD:      cmd,
-		VSIndex:  r.VSIndex,
-		Rsi:      "!" + strconv.Itoa(r.RsIndex),
-		NewPort:  r.NewPort,
-		NonLocal: 1,
+		CMD:       cmd,
+		VSIndex:   r.VSIndex,
+		Rsi:       "!" + strconv.Itoa(r.RsIndex),
+		NewPort:   r.NewPort,
+		Weight:    r.Weight,
+		Forward:   r.Forward,
+		Limit:     r.Limit,
+		RateLimit: r.RateLimit,
+		NonLocal:  1,
 	}
 
 	req, err := c.newRequest(cmd, rsa)
@@ -17,7 +17,7 @@ func TestGetRs(t *testing.T) {
 		datafile   string
 	}{
 		{2, "/accessv2", "test_data/showrs.json"},
-		{1, "/access/showrs?apikey=bar&rs=%211&vs=1", "test_data/showrs.xml"},
+		{1, "/access/showrs?rs=%211&vs=1", "test_data/showrs.xml"},
 	}
 	for _, tc := range testCases {
 		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
@@ -35,7 +35,7 @@ func TestGetRs(t *testing.T) {
 			}))
 
 			defer server.Close()
-			client := Client{server.Client(), "bar", "foo", "baz", server.URL, tc.apiversion}
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
 
 			rs, err := client.GetRs(1, 1)
 			ok(t, err)
@@ -54,7 +54,7 @@ func TestDelRs(t *testing.T) {
 		datafile   string
 	}{
 		{2, "/accessv2", "test_data/delrs.json"},
-		{1, "/access/delrs?apikey=bar&rs=%211&vs=1", "test_data/delrs.xml"},
+		{1, "/access/delrs?rs=%211&vs=1", "test_data/delrs.xml"},
 	}
 	for _, tc := range testCases {
 		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
@@ -72,7 +72,7 @@ func TestDelRs(t *testing.T) {
 			}))
 
 			defer server.Close()
-			client := Client{server.Client(), "bar", "foo", "baz", server.URL, tc.apiversion}
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
 
 			ar, err := client.DeleteRs(1, 1)
 			ok(t, err)
@@ -92,7 +92,7 @@ func TestCreateRs(t *testing.T) {
 		datafile   string
 	}{
 		{2, "/accessv2", "test_data/addrs.json"},
-		{1, "/access/addrs?apikey=bar&non_local=1&rs=10.10.10.10&rsport=8080&vs=1", "test_data/addrs.xml"},
+		{1, "/access/addrs?non_local=1&rs=10.10.10.10&rsport=8080&vs=1", "test_data/addrs.xml"},
 	}
 	for _, tc := range testCases {
 		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
@@ -110,7 +110,7 @@ func TestCreateRs(t *testing.T) {
 			}))
 
 			defer server.Close()
-			client := Client{server.Client(), "bar", "foo", "baz", server.URL, tc.apiversion}
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
 
 			r := &Rs{
 				VSIndex: 1,
@@ -199,7 +199,7 @@ func TestModifyRs(t *testing.T) {
 		datafile   string
 	}{
 		{2, "/accessv2", "test_data/modrs.json"},
-		{1, "/access/modrs?apikey=bar&newport=6443&non_local=1&rs=%211&vs=1", "test_data/modrs.xml"},
+		{1, "/access/modrs?newport=6443&non_local=1&rs=%211&vs=1", "test_data/modrs.xml"},
 	}
 	for _, tc := range testCases {
 		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
@@ -217,7 +217,7 @@ func TestModifyRs(t *testing.T) {
 			}))
 
 			defer server.Close()
-			client := Client{server.Client(), "bar", "foo", "baz", server.URL, tc.apiversion}
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
 			r := &Rs{
 				VSIndex: 1,
 				RsIndex: 1,
@@ -0,0 +1,240 @@
+package lmclient
+
+import (
+	"strconv"
+)
+
+type DNSSettings struct {
+	Nameservers   []string
+	SearchDomains []string
+}
+
+type NTPSettings struct {
+	Servers  []string
+	Timezone string
+}
+
+// SyslogSettings holds the remote syslog hosts receiving messages of each
+// severity and above.
+type SyslogSettings struct {
+	Emergency []string
+	Critical  []string
+	Error     []string
+	Warning   []string
+	Notice    []string
+	Info      []string
+}
+
+type SNMPSettings struct {
+	Enabled      bool
+	Community    string
+	Contact      string
+	Location     string
+	V3Enabled    bool
+	V3User       string
+	V3Password   string
+	TrapsEnabled bool
+	V1Sinks      []string
+	V2Sinks      []string
+}
+
+// EmailSettings holds the mail server used for admin alerts and the
+// recipients of alerts of each severity.
+type EmailSettings struct {
+	Server             string
+	Port               int
+	User               string
+	Password           string
+	Domain             string
+	InfoRecipients     []string
+	WarningRecipients  []string
+	ErrorRecipients    []string
+	CriticalRecipients []string
+}
+
+func (c *Client) GetHostname() (string, error) {
+	return c.getParam("hostname")
+}
+
+func (c *Client) SetHostname(hostname string) (bool, error) {
+	return c.convergeParams(map[string]string{"hostname": hostname})
+}
+
+func (c *Client) GetDNSSettings() (*DNSSettings, error) {
+	p, err := c.getParams([]string{"namserver", "searchlist"})
+	if err != nil {
+		return nil, err
+	}
+	return &DNSSettings{
+		Nameservers:   parseList(p["namserver"]),
+		SearchDomains: parseList(p["searchlist"]),
+	}, nil
+}
+
+// SetDNSSettings makes the DNS settings of the LoadMaster match s and
+// reports whether anything had to be changed.
+func (c *Client) SetDNSSettings(s *DNSSettings) (bool, error) {
+	return c.convergeParams(map[string]string{
+		"namserver":  formatList(s.Nameservers),
+		"searchlist": formatList(s.SearchDomains),
+	})
+}
+
+func (c *Client) GetNTPSettings() (*NTPSettings, error) {
+	p, err := c.getParams([]string{"ntphost", "timezone"})
+	if err != nil {
+		return nil, err
+	}
+	return &NTPSettings{
+		Servers:  parseList(p["ntphost"]),
+		Timezone: p["timezone"],
+	}, nil
+}
+
+func (c *Client) SetNTPSettings(s *NTPSettings) (bool, error) {
+	return c.convergeParams(map[string]string{
+		"ntphost":  formatList(s.Servers),
+		"timezone": s.Timezone,
+	})
+}
+
+func (c *Client) GetSyslogSettings() (*SyslogSettings, error) {
+	p, err := c.getParams([]string{"syslogemergency", "syslogcritical", "syslogerror", "syslogwarn", "syslognotice", "sysloginfo"})
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSettings{
+		Emergency: parseList(p["syslogemergency"]),
+		Critical:  parseList(p["syslogcritical"]),
+		Error:     parseList(p["syslogerror"]),
+		Warning:   parseList(p["syslogwarn"]),
+		Notice:    parseList(p["syslognotice"]),
+		Info:      parseList(p["sysloginfo"]),
+	}, nil
+}
+
+func (c *Client) SetSyslogSettings(s *SyslogSettings) (bool, error) {
+	return c.convergeParams(map[string]string{
+		"syslogemergency": formatList(s.Emergency),
+		"syslogcritical":  formatList(s.Critical),
+		"syslogerror":     formatList(s.Error),
+		"syslogwarn":      formatList(s.Warning),
+		"syslognotice":    formatList(s.Notice),
+		"sysloginfo":      formatList(s.Info),
+	})
+}
+
+func (c *Client) GetSNMPSettings() (*SNMPSettings, error) {
+	p, err := c.getParams([]string{"snmpenable", "snmpcommunity", "snmpcontact", "snmplocation", "snmpv3enable", "snmpv3user", "snmptrapenable", "snmpv1sink", "snmpv2sink"})
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := parseBool(p["snmpenable"])
+	if err != nil {
+		return nil, err
+	}
+	v3enabled, err := parseBool(p["snmpv3enable"])
+	if err != nil {
+		return nil, err
+	}
+	traps, err := parseBool(p["snmptrapenable"])
+	if err != nil {
+		return nil, err
+	}
+	// The SNMPv3 password can be set but is never returned
+	return &SNMPSettings{
+		Enabled:      enabled,
+		Community:    p["snmpcommunity"],
+		Contact:      p["snmpcontact"],
+		Location:     p["snmplocation"],
+		V3Enabled:    v3enabled,
+		V3User:       p["snmpv3user"],
+		TrapsEnabled: traps,
+		V1Sinks:      parseList(p["snmpv1sink"]),
+		V2Sinks:      parseList(p["snmpv2sink"]),
+	}, nil
+}
+
+// SetSNMPSettings makes the SNMP settings of the LoadMaster match s. As the
+// SNMPv3 password can not be read back it is set whenever it is not empty.
+// On API version 1 the password is sent as query parameter, see
+// PasswordInQuery.
+func (c *Client) SetSNMPSettings(s *SNMPSettings) (bool, error) {
+	if s.V3Password != "" {
+		if err := c.checkPasswordInQuery(); err != nil {
+			return false, err
+		}
+	}
+	changed, err := c.convergeParams(map[string]string{
+		"snmpenable":     formatBool(s.Enabled),
+		"snmpcommunity":  s.Community,
+		"snmpcontact":    s.Contact,
+		"snmplocation":   s.Location,
+		"snmpv3enable":   formatBool(s.V3Enabled),
+		"snmpv3user":     s.V3User,
+		"snmptrapenable": formatBool(s.TrapsEnabled),
+		"snmpv1sink":     formatList(s.V1Sinks),
+		"snmpv2sink":     formatList(s.V2Sinks),
+	})
+	if err != nil || s.V3Password == "" {
+		return changed, err
+	}
+	if _, err := c.setParam("snmpv3userpasswd", s.V3Password); err != nil {
+		return changed, err
+	}
+	return true, nil
+}
+
+func (c *Client) GetEmailSettings() (*EmailSettings, error) {
+	p, err := c.getParams([]string{"emailserver", "emailport", "emailuser", "emaildomain", "emailinfo", "emailwarn", "emailerror", "emailcritical"})
+	if err != nil {
+		return nil, err
+	}
+	port := 0
+	if p["emailport"] != "" {
+		port, err = strconv.Atoi(p["emailport"])
+		if err != nil {
+			return nil, err
+		}
+	}
+	// The mail server password can be set but is never returned
+	return &EmailSettings{
+		Server:             p["emailserver"],
+		Port:               port,
+		User:               p["emailuser"],
+		Domain:             p["emaildomain"],
+		InfoRecipients:     parseList(p["emailinfo"]),
+		WarningRecipients:  parseList(p["emailwarn"]),
+		ErrorRecipients:    parseList(p["emailerror"]),
+		CriticalRecipients: parseList(p["emailcritical"]),
+	}, nil
+}
+
+// SetEmailSettings makes the admin email alert settings of the LoadMaster
+// match s. As the mail server password can not be read back it is set
+// whenever it is not empty. On API version 1 the password is sent as query
+// parameter, see PasswordInQuery.
+func (c *Client) SetEmailSettings(s *EmailSettings) (bool, error) {
+	if s.Password != "" {
+		if err := c.checkPasswordInQuery(); err != nil {
+			return false, err
+		}
+	}
+	changed, err := c.convergeParams(map[string]string{
+		"emailserver":   s.Server,
+		"emailport":     strconv.Itoa(s.Port),
+		"emailuser":     s.User,
+		"emaildomain":   s.Domain,
+		"emailinfo":     formatList(s.InfoRecipients),
+		"emailwarn":     formatList(s.WarningRecipients),
+		"emailerror":    formatList(s.ErrorRecipients),
+		"emailcritical": formatList(s.CriticalRecipients),
+	})
+	if err != nil || s.Password == "" {
+		return changed, err
+	}
+	if _, err := c.setParam("emailpassword", s.Password); err != nil {
+		return changed, err
+	}
+	return true, nil
+}
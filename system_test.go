@@ -0,0 +1,174 @@
+package lmclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newParamServer starts a local HTTP server answering get and set commands
+// from params, recording the names of the parameters that were set.
+func newParamServer(t *testing.T, apiversion int, params map[string]string, set *[]string) *httptest.Server {
+	return httptest.NewServer(newParamHandler(t, apiversion, params, set))
+}
+
+func newParamHandler(t *testing.T, apiversion int, params map[string]string, set *[]string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		var cmd, param, value string
+		if apiversion == 1 {
+			cmd = req.URL.Path[len("/access/"):]
+			param = req.URL.Query().Get("param")
+			value = req.URL.Query().Get("value")
+		} else {
+			body, err := ioutil.ReadAll(req.Body)
+			ok(t, err)
+			var payload struct {
+				CMD   string `json:"cmd"`
+				Param string `json:"param"`
+				Value string `json:"value"`
+			}
+			ok(t, json.Unmarshal(body, &payload))
+			cmd, param, value = payload.CMD, payload.Param, payload.Value
+		}
+
+		var resp string
+		switch cmd {
+		case "get":
+			if apiversion == 1 {
+				resp = fmt.Sprintf(`<?xml version="1.0" encoding="ISO-8859-1"?>
+<Response stat="200" code="ok">
+<Success><Data><%s>%s</%s>
+</Data></Success>
+</Response>`, param, params[param], param)
+			} else {
+				b, _ := json.Marshal(map[string]interface{}{"code": 200, param: params[param], "status": "ok"})
+				resp = string(b)
+			}
+		case "set":
+			params[param] = value
+			*set = append(*set, param)
+			if apiversion == 1 {
+				resp = `<?xml version="1.0" encoding="ISO-8859-1"?>
+<Response stat="200" code="ok">
+<Success>Command completed ok</Success>
+</Response>`
+			} else {
+				resp = `{ "code": 200, "message": "Command completed ok", "status": "ok" }`
+			}
+		default:
+			t.Fatalf("unexpected command %s", cmd)
+		}
+		_, err := rw.Write([]byte(resp))
+		if err != nil {
+			fmt.Printf("Write failed: %v", err)
+		}
+	}
+}
+
+func TestSetDNSSettings(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			params := map[string]string{
+				"namserver":  "1.1.1.1",
+				"searchlist": "example.com",
+			}
+			var set []string
+			server := newParamServer(t, apiversion, params, &set)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: apiversion}
+
+			changed, err := client.SetDNSSettings(&DNSSettings{
+				Nameservers:   []string{"8.8.8.8", "1.1.1.1"},
+				SearchDomains: []string{"example.com"},
+			})
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, []string{"namserver"}, set)
+
+			dns, err := client.GetDNSSettings()
+			ok(t, err)
+			equals(t, []string{"8.8.8.8", "1.1.1.1"}, dns.Nameservers)
+			equals(t, []string{"example.com"}, dns.SearchDomains)
+		})
+	}
+}
+
+func TestSetSNMPSettings(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			params := map[string]string{
+				"snmpenable":     "Y",
+				"snmpcommunity":  "public",
+				"snmpcontact":    "",
+				"snmplocation":   "dc1",
+				"snmpv3enable":   "N",
+				"snmpv3user":     "",
+				"snmptrapenable": "N",
+				"snmpv1sink":     "",
+				"snmpv2sink":     "",
+			}
+			var set []string
+			server := newParamServer(t, apiversion, params, &set)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: apiversion}
+
+			snmp, err := client.GetSNMPSettings()
+			ok(t, err)
+			equals(t, true, snmp.Enabled)
+			equals(t, false, snmp.TrapsEnabled)
+
+			changed, err := client.SetSNMPSettings(snmp)
+			ok(t, err)
+			equals(t, false, changed)
+
+			snmp.TrapsEnabled = true
+			snmp.V2Sinks = []string{"10.0.0.5"}
+			changed, err = client.SetSNMPSettings(snmp)
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, []string{"snmptrapenable", "snmpv2sink"}, set)
+			equals(t, "Y", params["snmptrapenable"])
+
+			// String parameters are not compared as booleans
+			set = nil
+			snmp.Contact = "0"
+			changed, err = client.SetSNMPSettings(snmp)
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, []string{"snmpcontact"}, set)
+		})
+	}
+}
+
+func TestSecretParams(t *testing.T) {
+	params := map[string]string{"snmpenable": "Y", "snmpv3enable": "Y", "snmpv3user": "monitor"}
+	var set []string
+	server := newParamServer(t, 1, params, &set)
+	defer server.Close()
+	client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: 1}
+
+	snmp, err := client.GetSNMPSettings()
+	ok(t, err)
+	snmp.V3Password = "s3cr3t"
+	_, err = client.SetSNMPSettings(snmp)
+	equals(t, "Password would be sent as query parameter with API version 1, set PasswordInQuery to allow it", err.Error())
+	_, err = client.SetEmailSettings(&EmailSettings{Password: "s3cr3t"})
+	equals(t, "Password would be sent as query parameter with API version 1, set PasswordInQuery to allow it", err.Error())
+	equals(t, 0, len(set))
+
+	client.PasswordInQuery = true
+	changed, err := client.SetSNMPSettings(snmp)
+	ok(t, err)
+	equals(t, true, changed)
+	equals(t, "s3cr3t", params["snmpv3userpasswd"])
+
+	// The password is masked in the errors of failed requests
+	server.Close()
+	_, err = client.SetParam("emailpassword", "s3cr3t")
+	equals(t, false, strings.Contains(err.Error(), "s3cr3t"))
+	equals(t, true, strings.Contains(err.Error(), "value=***"))
+}
@@ -0,0 +1,190 @@
+package lmclient
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+type Templates struct {
+	XMLName  xml.Name   `xml:"Response"`
+	Template []Template `json:"template" xml:"Success>Data>template"`
+}
+
+// Template is a Virtual Service template installed on the LoadMaster.
+type Template struct {
+	Name    string `json:"name" xml:"name"`
+	Comment string `json:"comment" xml:"comment"`
+}
+
+func (c *Client) ListTemplates() ([]Template, error) {
+	cmd := "listtemplates"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var templates Templates
+	if err := c.sendCommand(cmd, payload, &templates); err != nil {
+		return nil, err
+	}
+	return templates.Template, nil
+}
+
+// UploadTemplate installs the template file read from r, in the format of
+// the templates downloaded from the vendor.
+func (c *Client) UploadTemplate(ctx context.Context, r io.Reader) (*ApiResponse, error) {
+	cmd := "uploadtemplate"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	req, err := c.newUploadRequest(ctx, cmd, payload, r)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(req)
+	if err != nil {
+		return nil, c.responseError(resp, err)
+	}
+
+	var ar ApiResponse
+	if err := c.decodeResponse(resp, &ar); err != nil {
+		return nil, err
+	}
+	if ar.Status != "ok" {
+		return nil, c.responseError(resp, errors.New("Template upload failed"))
+	}
+	return &ar, nil
+}
+
+func (c *Client) DeleteTemplate(name string) (*ApiResponse, error) {
+	cmd := "deltemplate"
+	payload := struct {
+		CMD  string `json:"cmd" qs:"-"`
+		Name string `json:"name" qs:"name"`
+	}{
+		CMD:  cmd,
+		Name: name,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+// CreateVsFromTemplate creates the Virtual Service described by the
+// template name on address and port. It returns the new Virtual Service
+// along with the SubVSs the template created for it.
+func (c *Client) CreateVsFromTemplate(name string, address string, port string) (*Vs, []Vs, error) {
+	defer c.InvalidateNameCache()
+	cmd := "addvs"
+	payload := struct {
+		CMD      string `json:"cmd" qs:"-"`
+		Address  string `json:"vs" qs:"vs"`
+		Port     string `json:"port" qs:"port"`
+		Template string `json:"template" qs:"template"`
+	}{
+		CMD:      cmd,
+		Address:  address,
+		Port:     port,
+		Template: name,
+	}
+
+	var created Vs
+	if err := c.sendCommand(cmd, payload, &created); err != nil {
+		return nil, nil, err
+	}
+
+	vs, err := c.GetVs(created.Index)
+	if err != nil {
+		return nil, nil, err
+	}
+	subvss := make([]Vs, 0, len(vs.SubVS))
+	for _, sub := range vs.SubVS {
+		subvs, err := c.GetVs(sub.VSIndex)
+		if err != nil {
+			return nil, nil, err
+		}
+		subvss = append(subvss, *subvs)
+	}
+	return vs, subvss, nil
+}
+
+// templateFile is the JSON document written by ExportTemplate: the
+// parameters of a Virtual Service followed by those of its SubVSs, named as
+// in the addvs and modvs commands. It is specific to this package and is
+// not the template format of the LoadMaster.
+type templateFile struct {
+	Name string       `json:"name"`
+	VS   []templateVs `json:"vs"`
+}
+
+type templateVs struct {
+	NickName   string `json:"NickName,omitempty"`
+	Type       string `json:"VStype,omitempty"`
+	Protocol   string `json:"prot,omitempty"`
+	Enable     string `json:"Enable"`
+	ForceL4    int    `json:"ForceL4,omitempty"`
+	ForceL7    int    `json:"ForceL7,omitempty"`
+	DefaultGW  string `json:"DefaultGW,omitempty"`
+	CheckType  string `json:"CheckType,omitempty"`
+	CheckUrl   string `json:"CheckUrl,omitempty"`
+	CheckCodes string `json:"CheckCodes,omitempty"`
+	CheckPort  string `json:"CheckPort,omitempty"`
+	*wafPayload
+	*espPayload
+	*limitsPayload
+}
+
+func newTemplateVs(v *Vs) templateVs {
+	t := templateVs{
+		NickName:      v.NickName,
+		Type:          v.Type,
+		Protocol:      v.Protocol,
+		Enable:        formatBool(v.Enable),
+		DefaultGW:     v.DefaultGW,
+		CheckType:     v.CheckType,
+		CheckUrl:      v.CheckUrl,
+		CheckCodes:    v.CheckCodes,
+		CheckPort:     v.CheckPort,
+		wafPayload:    v.WAF.payload(),
+		espPayload:    v.ESP.payload(),
+		limitsPayload: v.Limits.payload(),
+	}
+	if v.Layer == 4 {
+		t.ForceL4 = 1
+	} else {
+		t.ForceL7 = 1
+	}
+	return t
+}
+
+// ExportTemplate describes in JSON the Virtual Service with index vsindex
+// and its SubVSs, as read by GetVs, with their parameters named as in the
+// addvs and modvs commands. The description is named after the nickname of
+// the Virtual Service, its address, port and Real Servers are left out. It
+// is not a LoadMaster template and cannot be installed with UploadTemplate.
+func (c *Client) ExportTemplate(ctx context.Context, vsindex int) ([]byte, error) {
+	vs, err := c.getVs(ctx, vsindex)
+	if err != nil {
+		return nil, err
+	}
+
+	t := templateFile{
+		Name: vs.NickName,
+		VS:   []templateVs{newTemplateVs(vs)},
+	}
+	for _, sub := range vs.SubVS {
+		subvs, err := c.getVs(ctx, sub.VSIndex)
+		if err != nil {
+			return nil, err
+		}
+		t.VS = append(t.VS, newTemplateVs(subvs))
+	}
+	return json.MarshalIndent(t, "", "  ")
+}
@@ -0,0 +1,136 @@
+package lmclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestListTemplates(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{"listtemplates": "listtemplates"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			templates, err := client.ListTemplates()
+			ok(t, err)
+			equals(t, []Template{
+				{Name: "HTTPS Offloaded with WAF", Comment: "Generic HTTPS with SSL offload and WAF"},
+				{Name: "Exchange 2016 HTTPS Offloaded"},
+			}, templates)
+		})
+	}
+}
+
+func TestCreateVsFromTemplate(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{
+				"addvs":  "addvs",
+				"showvs": "showvssub",
+			}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			vs, subvss, err := client.CreateVsFromTemplate("HTTPS Offloaded with WAF", "192.168.1.239", "443")
+			ok(t, err)
+
+			equals(t, []string{"addvs", "showvs", "showvs"}, cmds)
+			equals(t, []SubVs{{VSIndex: 2, RsIndex: 1, Name: "-", Weight: 1000}}, vs.SubVS)
+			equals(t, 1, len(subvss))
+		})
+	}
+}
+
+func TestExportTemplate(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{"showvs": "showvssub"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			b, err := client.ExportTemplate(context.Background(), 1)
+			ok(t, err)
+			equals(t, []string{"showvs", "showvs"}, cmds)
+
+			var template map[string]interface{}
+			ok(t, json.Unmarshal(b, &template))
+			equals(t, "foo", template["name"])
+			vss := template["vs"].([]interface{})
+			equals(t, 2, len(vss))
+			vs := vss[0].(map[string]interface{})
+			equals(t, "http", vs["VStype"])
+			equals(t, float64(1), vs["ForceL7"])
+			equals(t, nil, vs["vs"])
+		})
+	}
+}
+
+func TestUploadTemplate(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var uploaded []byte
+			ext := ".json"
+			if apiversion == 1 {
+				ext = ".xml"
+			}
+			content, err := ioutil.ReadFile("test_data/ok" + ext)
+			ok(t, err)
+			// Start a local HTTP server recording the uploaded file
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				body, err := ioutil.ReadAll(req.Body)
+				ok(t, err)
+				if apiversion == 1 {
+					equals(t, "/access/uploadtemplate", req.URL.Path)
+					uploaded = body
+				} else {
+					var payload struct {
+						CMD  string `json:"cmd"`
+						Data string `json:"data"`
+					}
+					ok(t, json.Unmarshal(body, &payload))
+					equals(t, "uploadtemplate", payload.CMD)
+					uploaded, err = base64.StdEncoding.DecodeString(payload.Data)
+					ok(t, err)
+				}
+				_, err = rw.Write(content)
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			ar, err := client.UploadTemplate(context.Background(), strings.NewReader("template"))
+			ok(t, err)
+			equals(t, "ok", ar.Status)
+			equals(t, []byte("template"), uploaded)
+		})
+	}
+}
+
+func TestDeleteTemplate(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{"deltemplate": "ok"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			ar, err := client.DeleteTemplate("HTTPS Offloaded with WAF")
+			ok(t, err)
+			equals(t, "ok", ar.Status)
+			equals(t, []string{"deltemplate"}, cmds)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package lmclient
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Permission is a permission that can be granted to a local LoadMaster user.
+type Permission string
+
+const (
+	PermRealServers      Permission = "real"
+	PermVirtualServices  Permission = "vs"
+	PermRules            Permission = "rules"
+	PermBackup           Permission = "backup"
+	PermCerts            Permission = "certs"
+	PermIntermediateCert Permission = "cert3rd"
+	PermCertBackup       Permission = "certbackup"
+	PermUsers            Permission = "users"
+	PermGeo              Permission = "geo"
+	PermWAF              Permission = "waf"
+	PermSystemConfig     Permission = "sysconfig"
+	PermRoot             Permission = "root"
+)
+
+var knownPermissions = []Permission{
+	PermRealServers,
+	PermVirtualServices,
+	PermRules,
+	PermBackup,
+	PermCerts,
+	PermIntermediateCert,
+	PermCertBackup,
+	PermUsers,
+	PermGeo,
+	PermWAF,
+	PermSystemConfig,
+	PermRoot,
+}
+
+// Permissions is a set of permissions, sent to and received from the
+// LoadMaster as a comma separated list.
+type Permissions []Permission
+
+func (p Permissions) Has(perm Permission) bool {
+	for _, q := range p {
+		if q == perm {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Permissions) MarshalText() ([]byte, error) {
+	s := make([]string, len(p))
+	for i, perm := range p {
+		s[i] = string(perm)
+	}
+	return []byte(strings.Join(s, ",")), nil
+}
+
+func (p *Permissions) UnmarshalText(text []byte) error {
+	*p = nil
+	for _, s := range strings.Split(string(text), ",") {
+		s = strings.TrimSpace(s)
+		if s != "" {
+			*p = append(*p, Permission(s))
+		}
+	}
+	return nil
+}
+
+func (p Permissions) validate() error {
+	for _, perm := range p {
+		known := false
+		for _, k := range knownPermissions {
+			if perm == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("Unknown permission: %s", perm)
+		}
+	}
+	return nil
+}
+
+type Users struct {
+	XMLName xml.Name `xml:"Response"`
+	User    []User   `xml:"Success>Data>User"`
+}
+
+type User struct {
+	XMLName     xml.Name    `xml:"User"`
+	Name        string      `xml:"Name"`
+	Permissions Permissions `json:"Perms" xml:"Perms"`
+}
+
+func (c *Client) ListUsers() ([]User, error) {
+	cmd := "usershow"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var users Users
+	if err := c.sendCommand(cmd, payload, &users); err != nil {
+		return nil, err
+	}
+	return users.User, nil
+}
+
+func (c *Client) GetUser(name string) (*User, error) {
+	cmd := "usershow"
+	payload := struct {
+		CMD  string `json:"cmd" qs:"-"`
+		User string `json:"user" qs:"user"`
+	}{
+		CMD:  cmd,
+		User: name,
+	}
+
+	var users Users
+	if err := c.sendCommand(cmd, payload, &users); err != nil {
+		return nil, err
+	}
+	if len(users.User) == 0 {
+		return nil, notFound("User with name %s not found", name)
+	}
+	return &users.User[0], nil
+}
+
+// AddUser creates a local user and grants it permissions. The user is
+// deleted again if granting the permissions fails. On API version 1
+// the password is sent as query parameter, see PasswordInQuery.
+func (c *Client) AddUser(name string, password string, permissions Permissions) (*User, error) {
+	if err := permissions.validate(); err != nil {
+		return nil, err
+	}
+	if err := c.checkPasswordInQuery(); err != nil {
+		return nil, err
+	}
+
+	cmd := "useraddlocal"
+	payload := struct {
+		CMD      string `json:"cmd" qs:"-"`
+		User     string `json:"user" qs:"user"`
+		Password string `json:"password" qs:"password"`
+		Radius   string `json:"radius" qs:"radius"`
+	}{
+		CMD:      cmd,
+		User:     name,
+		Password: password,
+		Radius:   "N",
+	}
+
+	if _, err := c.sendApiCommand(cmd, payload); err != nil {
+		return nil, err
+	}
+
+	if len(permissions) > 0 {
+		if _, err := c.SetUserPermissions(name, permissions); err != nil {
+			// Do not leave the user behind without its permissions
+			if _, derr := c.DeleteUser(name); derr != nil {
+				return nil, fmt.Errorf("Setting permissions of user %s failed: %v, deleting the user failed too: %v", name, err, derr)
+			}
+			return nil, err
+		}
+	}
+
+	return &User{Name: name, Permissions: permissions}, nil
+}
+
+// SetUserPermissions replaces the permissions of a local user.
+func (c *Client) SetUserPermissions(name string, permissions Permissions) (*ApiResponse, error) {
+	if err := permissions.validate(); err != nil {
+		return nil, err
+	}
+	perms, _ := permissions.MarshalText()
+
+	cmd := "usersetperms"
+	payload := struct {
+		CMD   string `json:"cmd" qs:"-"`
+		User  string `json:"user" qs:"user"`
+		Perms string `json:"perms" qs:"perms"`
+	}{
+		CMD:   cmd,
+		User:  name,
+		Perms: string(perms),
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+// SetUserPassword changes the password of a local user. On API version 1
+// the password is sent as query parameter, see PasswordInQuery.
+func (c *Client) SetUserPassword(name string, password string) (*ApiResponse, error) {
+	if err := c.checkPasswordInQuery(); err != nil {
+		return nil, err
+	}
+
+	cmd := "userchangelocpass"
+	payload := struct {
+		CMD      string `json:"cmd" qs:"-"`
+		User     string `json:"user" qs:"user"`
+		Password string `json:"password" qs:"password"`
+		Radius   string `json:"radius" qs:"radius"`
+	}{
+		CMD:      cmd,
+		User:     name,
+		Password: password,
+		Radius:   "N",
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+func (c *Client) DeleteUser(name string) (*ApiResponse, error) {
+	cmd := "userdellocal"
+	payload := struct {
+		CMD  string `json:"cmd" qs:"-"`
+		User string `json:"user" qs:"user"`
+	}{
+		CMD:  cmd,
+		User: name,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
@@ -0,0 +1,156 @@
+package lmclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListUsers(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+		datafile   string
+	}{
+		{2, "/accessv2", "test_data/usershow.json"},
+		{1, "/access/usershow", "test_data/usershow.xml"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			content, err := ioutil.ReadFile(tc.datafile)
+			ok(t, err)
+			// Start a local HTTP server
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// Test request parameters
+				equals(t, req.URL.String(), tc.url)
+				// Send response to be tested
+				_, err := rw.Write([]byte(content))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
+
+			users, err := client.ListUsers()
+			ok(t, err)
+
+			equals(t, len(users), 2)
+			equals(t, users[0].Name, "alice")
+			equals(t, users[0].Permissions, Permissions{PermRealServers, PermVirtualServices, PermRules})
+			equals(t, users[1].Permissions.Has(PermBackup), true)
+		})
+	}
+}
+
+func TestSetUserPermissions(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+		datafile   string
+	}{
+		{2, "/accessv2", "test_data/usersetperms.json"},
+		{1, "/access/usersetperms?perms=real%2Cvs&user=alice", "test_data/usersetperms.xml"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			content, err := ioutil.ReadFile(tc.datafile)
+			ok(t, err)
+			// Start a local HTTP server
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// Test request parameters
+				equals(t, req.URL.String(), tc.url)
+				// Send response to be tested
+				_, err := rw.Write([]byte(content))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
+
+			ar, err := client.SetUserPermissions("alice", Permissions{PermRealServers, PermVirtualServices})
+			ok(t, err)
+			equals(t, ar.Status, "ok")
+
+			_, err = client.SetUserPermissions("alice", Permissions{"everything"})
+			equals(t, "Unknown permission: everything", err.Error())
+		})
+	}
+}
+
+func TestSetUserPassword(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+		datafile   string
+	}{
+		{2, "/accessv2", "test_data/ok.json"},
+		{1, "/access/userchangelocpass?password=s3cr3t&radius=N&user=alice", "test_data/ok.xml"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			content, err := ioutil.ReadFile(tc.datafile)
+			ok(t, err)
+			// Start a local HTTP server
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// Test request parameters
+				equals(t, req.URL.String(), tc.url)
+				// Send response to be tested
+				_, err := rw.Write([]byte(content))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: tc.apiversion}
+			if tc.apiversion == 1 {
+				_, err := client.SetUserPassword("alice", "s3cr3t")
+				equals(t, "Password would be sent as query parameter with API version 1, set PasswordInQuery to allow it", err.Error())
+				client.PasswordInQuery = true
+			}
+
+			ar, err := client.SetUserPassword("alice", "s3cr3t")
+			ok(t, err)
+			equals(t, ar.Status, "ok")
+		})
+	}
+}
+
+func TestAddUserRollback(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			// usersetperms is missing, so granting permissions fails
+			server := newCommandServer(t, apiversion, map[string]string{
+				"useraddlocal": "ok",
+				"userdellocal": "ok",
+			}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion, PasswordInQuery: true}
+
+			_, err := client.AddUser("alice", "s3cr3t", Permissions{PermRealServers})
+			equals(t, "status: 404, body: ", err.Error())
+			equals(t, []string{"useraddlocal", "usersetperms", "userdellocal"}, cmds)
+
+			// Deleting fails too, the user is reported as left behind
+			cmds = nil
+			failing := newCommandServer(t, apiversion, map[string]string{"useraddlocal": "ok"}, &cmds)
+			defer failing.Close()
+			broken := Client{HttpClient: failing.Client(), ApiKey: "bar", RestUrl: failing.URL, Version: apiversion, PasswordInQuery: true}
+			_, err = broken.AddUser("alice", "s3cr3t", Permissions{PermRealServers})
+			equals(t, "Setting permissions of user alice failed: status: 404, body: , deleting the user failed too: status: 404, body: ", err.Error())
+
+			cmds = nil
+			user, err := client.AddUser("bob", "s3cr3t", nil)
+			ok(t, err)
+			equals(t, "bob", user.Name)
+			equals(t, []string{"useraddlocal"}, cmds)
+		})
+	}
+}
@@ -2,11 +2,11 @@ package lmclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"log"
 	"math/rand"
 	"regexp"
 	"strconv"
@@ -22,6 +22,11 @@ type VsListed struct {
 	XMLName  xml.Name `xml:"VS"`
 	Index    int      `xml:"Index"`
 	NickName string   `xml:"NickName"`
+	Address  string   `json:"VSAddress" xml:"VSAddress"`
+	Port     string   `json:"VSPort" xml:"VSPort"`
+	Protocol string   `xml:"Protocol"`
+	Status   string   `xml:"Status"`
+	Enable   bool     `xml:"Enable"`
 }
 
 type Vs struct {
@@ -42,6 +47,62 @@ type Vs struct {
 	CheckUrl   string   `xml:"Success>Data>CheckUrl"`
 	CheckCodes string   `xml:"Success>Data>CheckCodes"`
 	CheckPort  string   `xml:"Success>Data>CheckPort"`
+	Rs         []Rs     `xml:"Success>Data>Rs"`
+	SubVS      []SubVs  `xml:"Success>Data>SubVS"`
+	// Rules assigned to the Virtual Service, by the stage they apply at.
+	RequestRules    []string `xml:"Success>Data>RequestRules>Name"`
+	ResponseRules   []string `xml:"Success>Data>ResponseRules>Name"`
+	MatchBodyRules  []string `xml:"Success>Data>MatchBodyRules>Name"`
+	PreProcessRules []string `xml:"Success>Data>PreProcessRules>Name"`
+	// WAF, ESP and Limits are decoded from the same flat response as the
+	// fields above, and are nil if it has none of their fields.
+	WAF    *VsWAF    `json:"-" xml:"-"`
+	ESP    *VsESP    `json:"-" xml:"-"`
+	Limits *VsLimits `json:"-" xml:"-"`
+}
+
+// UnmarshalJSON decodes WAF, ESP and Limits as embedded pointers, which
+// are only allocated when one of their fields is present.
+func (v *Vs) UnmarshalJSON(b []byte) error {
+	type Plain Vs
+	var decoded struct {
+		Plain
+		*VsWAF
+		*VsESP
+		*VsLimits
+	}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	*v = Vs(decoded.Plain)
+	v.WAF, v.ESP, v.Limits = decoded.VsWAF, decoded.VsESP, decoded.VsLimits
+	return nil
+}
+
+// UnmarshalXML decodes WAF, ESP and Limits like UnmarshalJSON.
+func (v *Vs) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type Plain Vs
+	var decoded struct {
+		Plain
+		*VsWAF
+		*VsESP
+		*VsLimits
+	}
+	if err := d.DecodeElement(&decoded, &start); err != nil {
+		return err
+	}
+	*v = Vs(decoded.Plain)
+	v.WAF, v.ESP, v.Limits = decoded.VsWAF, decoded.VsESP, decoded.VsLimits
+	return nil
+}
+
+// SubVs is a SubVS of a Virtual Service as listed by GetVs. Use GetVs with
+// VSIndex for its full configuration.
+type SubVs struct {
+	VSIndex int
+	RsIndex int
+	Name    string
+	Weight  int
 }
 
 func SleepRandom() {
@@ -51,20 +112,18 @@ func SleepRandom() {
 }
 
 func (c *Client) GetAllVs() ([]VsListed, error) {
+	return c.getAllVs(context.Background())
+}
+
+func (c *Client) getAllVs(ctx context.Context) ([]VsListed, error) {
 	cmd := "listvs"
 	payload := struct {
-		CMD     string `json:"cmd" qs:"-"`
-		ApiKey  string `json:"apikey,omitempty" qs:"apikey,omitempty"`
-		ApiUser string `json:"apiuser,omitempty" qs:"-"`
-		ApiPass string `json:"apipass,omitempty" qs:"-"`
+		CMD string `json:"cmd" qs:"-"`
 	}{
-		CMD:     cmd,
-		ApiKey:  c.ApiKey,
-		ApiUser: c.ApiUser,
-		ApiPass: c.ApiPass,
+		CMD: cmd,
 	}
 
-	req, err := c.newRequest("listvs", payload)
+	req, err := c.newRequestWithContext(ctx, cmd, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -74,56 +133,58 @@ func (c *Client) GetAllVs() ([]VsListed, error) {
 		return nil, err
 	}
 	var vss Vss
-	if c.Version == 1 {
-		reader := bytes.NewReader(resp)
-		decoder := xml.NewDecoder(reader)
-		decoder.CharsetReader = makeCharsetReader
-		err = decoder.Decode(&vss)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		err = json.Unmarshal(resp, &vss)
-		if err != nil {
-			return nil, err
-		}
+	if err := c.decodeResponse(resp, &vss); err != nil {
+		return nil, err
 	}
 	return vss.VS, nil
 
 }
 
+// GetVsByName returns the only Virtual Service with the nickname. The error
+// matches ErrNotFound if there is none and is an *AmbiguousError if there
+// are several.
 func (c *Client) GetVsByName(nickname string) (*VsListed, error) {
 	vss, err := c.GetAllVs()
 	if err != nil {
 		return nil, err
 	}
+	return vsByName(vss, nickname)
+}
 
+// vsByName returns the only Virtual Service of vss with the nickname, with
+// the errors of GetVsByName.
+func vsByName(vss []VsListed, nickname string) (*VsListed, error) {
+	var matches []VsListed
 	for _, vs := range vss {
 		if vs.NickName == nickname {
-			return &vs, nil
+			matches = append(matches, vs)
 		}
 	}
 
-	return nil, fmt.Errorf("Virtual Service with name %s not found", nickname)
+	switch len(matches) {
+	case 0:
+		return nil, notFound("Virtual Service with name %s not found", nickname)
+	case 1:
+		return &matches[0], nil
+	}
+	return nil, &AmbiguousError{Name: nickname, Indexes: vsIndexesByName(matches)[nickname]}
 }
 
 func (c *Client) GetVs(index int) (*Vs, error) {
+	return c.getVs(context.Background(), index)
+}
+
+func (c *Client) getVs(ctx context.Context, index int) (*Vs, error) {
 	cmd := "showvs"
 	vsa := struct {
-		Index   int    `json:"vs" qs:"vs"`
-		CMD     string `json:"cmd" qs:"-"`
-		ApiKey  string `json:"apikey,omitempty" qs:"apikey,omitempty"`
-		ApiUser string `json:"apiuser,omitempty" qs:"-"`
-		ApiPass string `json:"apipass,omitempty" qs:"-"`
+		Index int    `json:"vs" qs:"vs"`
+		CMD   string `json:"cmd" qs:"-"`
 	}{
-		Index:   index,
-		CMD:     cmd,
-		ApiKey:  c.ApiKey,
-		ApiUser: c.ApiUser,
-		ApiPass: c.ApiPass,
+		Index: index,
+		CMD:   cmd,
 	}
 
-	req, err := c.newRequest(cmd, vsa)
+	req, err := c.newRequestWithContext(ctx, cmd, vsa)
 	if err != nil {
 		return nil, err
 	}
@@ -180,6 +241,7 @@ func (c *Client) GetVs(index int) (*Vs, error) {
 }
 
 func (c *Client) CreateVs(v *Vs) (*Vs, error) {
+	defer c.InvalidateNameCache()
 	cmd := "addvs"
 
 	var enable string
@@ -199,9 +261,6 @@ func (c *Client) CreateVs(v *Vs) (*Vs, error) {
 	}
 
 	vsa := struct {
-		ApiKey     string `json:"apikey,omitempty" qs:"apikey,omitempty"`
-		ApiUser    string `json:"apiuser,omitempty" qs:"-"`
-		ApiPass    string `json:"apipass,omitempty" qs:"-"`
 		CMD        string `json:"cmd" qs:"-"`
 		Address    string `json:"vs" qs:"vs"`
 		Port       string `json:"port" qs:"port"`
@@ -216,24 +275,23 @@ func (c *Client) CreateVs(v *Vs) (*Vs, error) {
 		CheckUrl   string `json:"CheckUrl,omitempty" qs:"checkurl,omitempty"`
 		CheckCodes string `json:"CheckCodes,omitempty" qs:"checkcodes,omitempty"`
 		CheckPort  string `json:"CheckPort,omitempty" qs:"checkport,omitempty"`
+		*limitsPayload
 	}{
-		ApiKey:     c.ApiKey,
-		ApiUser:    c.ApiUser,
-		ApiPass:    c.ApiPass,
-		CMD:        cmd,
-		Address:    v.Address,
-		Port:       v.Port,
-		NickName:   v.NickName,
-		Type:       v.Type,
-		Protocol:   v.Protocol,
-		Enable:     enable,
-		ForceL4:    forcel4,
-		ForceL7:    forcel7,
-		DefaultGW:  v.DefaultGW,
-		CheckType:  v.CheckType,
-		CheckUrl:   v.CheckUrl,
-		CheckCodes: v.CheckCodes,
-		CheckPort:  v.CheckPort,
+		CMD:           cmd,
+		Address:       v.Address,
+		Port:          v.Port,
+		NickName:      v.NickName,
+		Type:          v.Type,
+		Protocol:      v.Protocol,
+		Enable:        enable,
+		ForceL4:       forcel4,
+		ForceL7:       forcel7,
+		DefaultGW:     v.DefaultGW,
+		CheckType:     v.CheckType,
+		CheckUrl:      v.CheckUrl,
+		CheckCodes:    v.CheckCodes,
+		CheckPort:     v.CheckPort,
+		limitsPayload: v.Limits.payload(),
 	}
 
 	req, err := c.newRequest(cmd, vsa)
@@ -274,7 +332,7 @@ func (c *Client) CreateVs(v *Vs) (*Vs, error) {
 	cvs, err := c.GetVs(vs.Index)
 	if err != nil {
 		if err.Error() == "Code: 422 Message: Unknown VS" {
-			log.Printf("[WARN] Newly created VS not found")
+			c.logger().Warn("Newly created VS not found", "index", vs.Index)
 			SleepRandom()
 			return nil, err
 		}
@@ -285,19 +343,14 @@ func (c *Client) CreateVs(v *Vs) (*Vs, error) {
 }
 
 func (c *Client) DeleteVs(index int) (*ApiResponse, error) {
+	defer c.InvalidateNameCache()
 	cmd := "delvs"
 	vsa := struct {
-		Index   int    `json:"vs" qs:"vs"`
-		CMD     string `json:"cmd" qs:"-"`
-		ApiKey  string `json:"apikey,omitempty" qs:"apikey,omitempty"`
-		ApiUser string `json:"apiuser,omitempty" qs:"-"`
-		ApiPass string `json:"apipass,omitempty" qs:"-"`
+		Index int    `json:"vs" qs:"vs"`
+		CMD   string `json:"cmd" qs:"-"`
 	}{
-		Index:   index,
-		CMD:     cmd,
-		ApiKey:  c.ApiKey,
-		ApiUser: c.ApiUser,
-		ApiPass: c.ApiPass,
+		Index: index,
+		CMD:   cmd,
 	}
 
 	req, err := c.newRequest(cmd, vsa)
@@ -334,6 +387,7 @@ func (c *Client) DeleteVs(index int) (*ApiResponse, error) {
 }
 
 func (c *Client) ModifyVs(v *Vs) (*Vs, error) {
+	defer c.InvalidateNameCache()
 	cmd := "modvs"
 
 	vsport, _ := strconv.Atoi(v.VSPort)
@@ -357,9 +411,6 @@ func (c *Client) ModifyVs(v *Vs) (*Vs, error) {
 	vsa := struct {
 		Index      int    `json:"vs" qs:"vs"`
 		CMD        string `json:"cmd" qs:"-"`
-		ApiKey     string `json:"apikey,omitempty" qs:"apikey,omitempty"`
-		ApiUser    string `json:"apiuser,omitempty" qs:"-"`
-		ApiPass    string `json:"apipass,omitempty" qs:"-"`
 		Address    string `json:"vsaddress" qs:"vsaddress"`
 		Port       string `json:"port" qs:"port"`
 		VSPort     int    `json:"vsport" qs:"vsport,omitempty"`
@@ -374,26 +425,29 @@ func (c *Client) ModifyVs(v *Vs) (*Vs, error) {
 		CheckUrl   string `json:"CheckUrl,omitempty" qs:"checkurl,omitempty"`
 		CheckCodes string `json:"CheckCodes,omitempty" qs:"checkcodes,omitempty"`
 		CheckPort  string `json:"CheckPort,omitempty" qs:"checkport,omitempty"`
+		*wafPayload
+		*espPayload
+		*limitsPayload
 	}{
-		Index:      v.Index,
-		CMD:        cmd,
-		ApiKey:     c.ApiKey,
-		ApiUser:    c.ApiUser,
-		ApiPass:    c.ApiPass,
-		Address:    v.Address,
-		Port:       v.Port,
-		VSPort:     vsport,
-		NickName:   v.NickName,
-		Type:       v.Type,
-		Protocol:   v.Protocol,
-		Enable:     enable,
-		ForceL4:    forcel4,
-		ForceL7:    forcel7,
-		DefaultGW:  v.DefaultGW,
-		CheckType:  v.CheckType,
-		CheckUrl:   v.CheckUrl,
-		CheckCodes: v.CheckCodes,
-		CheckPort:  v.CheckPort,
+		Index:         v.Index,
+		CMD:           cmd,
+		Address:       v.Address,
+		Port:          v.Port,
+		VSPort:        vsport,
+		NickName:      v.NickName,
+		Type:          v.Type,
+		Protocol:      v.Protocol,
+		Enable:        enable,
+		ForceL4:       forcel4,
+		ForceL7:       forcel7,
+		DefaultGW:     v.DefaultGW,
+		CheckType:     v.CheckType,
+		CheckUrl:      v.CheckUrl,
+		CheckCodes:    v.CheckCodes,
+		CheckPort:     v.CheckPort,
+		wafPayload:    v.WAF.payload(),
+		espPayload:    v.ESP.payload(),
+		limitsPayload: v.Limits.payload(),
 	}
 
 	req, err := c.newRequest(cmd, vsa)
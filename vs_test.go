@@ -1,6 +1,8 @@
 package lmclient
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -21,7 +23,7 @@ func TestGetAllVs(t *testing.T) {
 		datafile   string
 	}{
 		{2, "/accessv2", "test_data/listvs.json"},
-		{1, "/access/listvs?apikey=bar", "test_data/listvs.xml"},
+		{1, "/access/listvs", "test_data/listvs.xml"},
 	}
 	for _, tc := range testCases {
 		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
@@ -39,7 +41,7 @@ func TestGetAllVs(t *testing.T) {
 			}))
 
 			defer server.Close()
-			client := Client{server.Client(), "bar", "foo", "baz", server.URL, tc.apiversion}
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
 
 			vs, err := client.GetAllVs()
 			ok(t, err)
@@ -58,7 +60,7 @@ func TestGetVs(t *testing.T) {
 		datafile   string
 	}{
 		{2, "/accessv2", "test_data/showvs.json"},
-		{1, "/access/showvs?apikey=bar&vs=1", "test_data/showvs.xml"},
+		{1, "/access/showvs?vs=1", "test_data/showvs.xml"},
 	}
 	for _, tc := range testCases {
 		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
@@ -76,7 +78,7 @@ func TestGetVs(t *testing.T) {
 			}))
 
 			defer server.Close()
-			client := Client{server.Client(), "bar", "foo", "baz", server.URL, tc.apiversion}
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
 
 			vs, err := client.GetVs(1)
 			ok(t, err)
@@ -88,6 +90,25 @@ func TestGetVs(t *testing.T) {
 
 }
 
+func TestVsSections(t *testing.T) {
+	var vs Vs
+	ok(t, json.Unmarshal([]byte(`{"Index": 1, "NickName": "foo"}`), &vs))
+	equals(t, "foo", vs.NickName)
+	equals(t, true, vs.WAF == nil && vs.ESP == nil && vs.Limits == nil)
+
+	vs = Vs{}
+	ok(t, json.Unmarshal([]byte(`{"Index": 1, "InterceptMode": 2, "MaxConnsLimit": 10}`), &vs))
+	equals(t, &VsWAF{InterceptMode: WAFModeOwasp}, vs.WAF)
+	equals(t, true, vs.ESP == nil)
+	equals(t, &VsLimits{MaxConnsLimit: 10}, vs.Limits)
+
+	vs = Vs{}
+	ok(t, xml.Unmarshal([]byte(`<Response><Success><Data><Index>1</Index><EspEnabled>true</EspEnabled></Data></Success></Response>`), &vs))
+	equals(t, 1, vs.Index)
+	equals(t, true, vs.WAF == nil && vs.Limits == nil)
+	equals(t, true, vs.ESP.EspEnabled)
+}
+
 func TestDelVs(t *testing.T) {
 	testCases := []struct {
 		apiversion int
@@ -95,7 +116,7 @@ func TestDelVs(t *testing.T) {
 		datafile   string
 	}{
 		{2, "/accessv2", "test_data/delvs.json"},
-		{1, "/access/delvs?apikey=bar&vs=1", "test_data/delvs.xml"},
+		{1, "/access/delvs?vs=1", "test_data/delvs.xml"},
 	}
 	for _, tc := range testCases {
 		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
@@ -113,7 +134,7 @@ func TestDelVs(t *testing.T) {
 			}))
 
 			defer server.Close()
-			client := Client{server.Client(), "bar", "foo", "baz", server.URL, tc.apiversion}
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
 
 			ar, err := client.DeleteVs(1)
 			ok(t, err)
@@ -133,7 +154,7 @@ func TestCreateVs(t *testing.T) {
 		datafile   string
 	}{
 		{2, []string{"/accessv2"}, "test_data/addvs.json"},
-		{1, []string{"/access/addvs?Enable=Y&apikey=bar&checkcodes=303+606+909&checkport=8080&checktype=https&checkurl=%2Fhealthz&defaultgw=192.168.1.1&forcel4=1&forcel7=0&port=6443&prot=tcp&vs=192.168.1.235&vstype=http2", "/access/showvs?apikey=bar&vs=1"}, "test_data/addvs.xml"},
+		{1, []string{"/access/addvs?Enable=Y&checkcodes=303+606+909&checkport=8080&checktype=https&checkurl=%2Fhealthz&defaultgw=192.168.1.1&forcel4=1&forcel7=0&port=6443&prot=tcp&vs=192.168.1.235&vstype=http2", "/access/showvs?vs=1"}, "test_data/addvs.xml"},
 	}
 	for _, tc := range testCases {
 		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
@@ -156,7 +177,7 @@ func TestCreateVs(t *testing.T) {
 			}))
 
 			defer server.Close()
-			client := Client{server.Client(), "bar", "foo", "baz", server.URL, tc.apiversion}
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
 
 			v := &Vs{
 				Address:    "192.168.1.235",
@@ -262,7 +283,7 @@ func TestModifyVs(t *testing.T) {
 		datafile   string
 	}{
 		{2, "/accessv2", "test_data/modvs.json"},
-		{1, "/access/modvs?Enable=Y&apikey=bar&checkcodes=303+606+909&checkport=8080&checktype=https&checkurl=%2Fhealthz&defaultgw=192.168.1.1&forcel4=0&forcel7=1&port=6443&prot=tcp&vs=1&vsaddress=192.168.1.215", "test_data/modvs.xml"},
+		{1, "/access/modvs?Enable=Y&checkcodes=303+606+909&checkport=8080&checktype=https&checkurl=%2Fhealthz&defaultgw=192.168.1.1&forcel4=0&forcel7=1&port=6443&prot=tcp&vs=1&vsaddress=192.168.1.215", "test_data/modvs.xml"},
 	}
 	for _, tc := range testCases {
 		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
@@ -280,7 +301,7 @@ func TestModifyVs(t *testing.T) {
 			}))
 
 			defer server.Close()
-			client := Client{server.Client(), "bar", "foo", "baz", server.URL, tc.apiversion}
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", ApiUser: "foo", ApiPass: "baz", RestUrl: server.URL, Version: tc.apiversion}
 			v := &Vs{
 				Index:      1,
 				Address:    "192.168.1.215",
@@ -0,0 +1,291 @@
+package lmclient
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// WAF modes of a Virtual Service, set in VsWAF.InterceptMode.
+const (
+	WAFModeDisabled = 0
+	WAFModeLegacy   = 1
+	WAFModeOwasp    = 2
+)
+
+// VsWAF is the Web Application Firewall configuration of a Virtual Service.
+// It is filled in by GetVs, and ModifyVs only sends it when it is set, so a
+// Vs without it leaves the WAF configuration alone.
+type VsWAF struct {
+	InterceptMode           int      `xml:"Success>Data>InterceptMode"`
+	Intercept               bool     `xml:"Success>Data>Intercept"`
+	InterceptOpts           []string `xml:"Success>Data>InterceptOpts>Opt"`
+	AlertThreshold          int      `xml:"Success>Data>AlertThreshold"`
+	OwaspOpts               []string `xml:"Success>Data>OwaspOpts>Opt"`
+	BlockingParanoia        int      `xml:"Success>Data>BlockingParanoia"`
+	ExecutingParanoia       int      `xml:"Success>Data>ExecutingParanoia"`
+	AnomalyScoringThreshold int      `xml:"Success>Data>AnomalyScoringThreshold"`
+	IPReputationBlocking    bool     `xml:"Success>Data>IPReputationBlocking"`
+	PCRELimit               int      `xml:"Success>Data>PCRELimit"`
+	JSONDLimit              int      `xml:"Success>Data>JSONDLimit"`
+	BodyLimit               int      `xml:"Success>Data>BodyLimit"`
+}
+
+// wafPayload is the part of the modvs payload setting the WAF
+// configuration of a Virtual Service.
+type wafPayload struct {
+	InterceptMode           int    `json:"InterceptMode" qs:"InterceptMode"`
+	Intercept               string `json:"Intercept" qs:"Intercept"`
+	InterceptOpts           string `json:"InterceptOpts,omitempty" qs:"InterceptOpts,omitempty"`
+	AlertThreshold          int    `json:"AlertThreshold" qs:"AlertThreshold"`
+	OwaspOpts               string `json:"OwaspOpts,omitempty" qs:"OwaspOpts,omitempty"`
+	BlockingParanoia        int    `json:"BlockingParanoia" qs:"BlockingParanoia"`
+	ExecutingParanoia       int    `json:"ExecutingParanoia" qs:"ExecutingParanoia"`
+	AnomalyScoringThreshold int    `json:"AnomalyScoringThreshold" qs:"AnomalyScoringThreshold"`
+	IPReputationBlocking    string `json:"IPReputationBlocking" qs:"IPReputationBlocking"`
+	PCRELimit               int    `json:"PCRELimit" qs:"PCRELimit"`
+	JSONDLimit              int    `json:"JSONDLimit" qs:"JSONDLimit"`
+	BodyLimit               int    `json:"BodyLimit" qs:"BodyLimit"`
+}
+
+func (w *VsWAF) payload() *wafPayload {
+	if w == nil {
+		return nil
+	}
+	return &wafPayload{
+		InterceptMode:           w.InterceptMode,
+		Intercept:               formatBool(w.Intercept),
+		InterceptOpts:           strings.Join(w.InterceptOpts, ";"),
+		AlertThreshold:          w.AlertThreshold,
+		OwaspOpts:               strings.Join(w.OwaspOpts, ";"),
+		BlockingParanoia:        w.BlockingParanoia,
+		ExecutingParanoia:       w.ExecutingParanoia,
+		AnomalyScoringThreshold: w.AnomalyScoringThreshold,
+		IPReputationBlocking:    formatBool(w.IPReputationBlocking),
+		PCRELimit:               w.PCRELimit,
+		JSONDLimit:              w.JSONDLimit,
+		BodyLimit:               w.BodyLimit,
+	}
+}
+
+// WAFRules lists the WAF rules installed on the LoadMaster: the commercial
+// rule sets, custom rule files and custom data files.
+type WAFRules struct {
+	XMLName    xml.Name `xml:"Response"`
+	Rules      []string `xml:"Success>Data>Rules>Name"`
+	Custom     []string `json:"CustomRules" xml:"Success>Data>CustomRules>Name"`
+	CustomData []string `xml:"Success>Data>CustomData>Name"`
+}
+
+// WAFSettings holds the update schedule of the commercial WAF rules.
+// Updates are downloaded daily if AutoUpdate is set, and installed at
+// InstallTime (hour of the day) if AutoInstall is set.
+type WAFSettings struct {
+	XMLName     xml.Name `xml:"Response" json:"-"`
+	AutoUpdate  bool     `xml:"Success>Data>AutoUpdate"`
+	AutoInstall bool     `xml:"Success>Data>AutoInstall"`
+	InstallTime int      `json:"InstallTimeHour" xml:"Success>Data>InstallTimeHour"`
+	LastUpdated string   `xml:"Success>Data>LastUpdated"`
+}
+
+func (c *Client) ListWAFRules() (*WAFRules, error) {
+	cmd := "listwafrules"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var rules WAFRules
+	if err := c.sendCommand(cmd, payload, &rules); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// UploadWAFCustomRule uploads a custom rule file read from r, or a tar.gz
+// archive of rule files, under filename.
+func (c *Client) UploadWAFCustomRule(ctx context.Context, filename string, r io.Reader) (*ApiResponse, error) {
+	return c.uploadWAFFile(ctx, "addwafcustomrule", filename, r)
+}
+
+// UploadWAFCustomData uploads a data file read from r that custom rules
+// can refer to.
+func (c *Client) UploadWAFCustomData(ctx context.Context, filename string, r io.Reader) (*ApiResponse, error) {
+	return c.uploadWAFFile(ctx, "addwafcustomdata", filename, r)
+}
+
+func (c *Client) uploadWAFFile(ctx context.Context, cmd string, filename string, r io.Reader) (*ApiResponse, error) {
+	if filename == "" {
+		return nil, errors.New("WAF file name must not be empty")
+	}
+
+	payload := struct {
+		CMD      string `json:"cmd" qs:"-"`
+		Filename string `json:"filename" qs:"filename"`
+	}{
+		CMD:      cmd,
+		Filename: filename,
+	}
+
+	req, err := c.newUploadRequest(ctx, cmd, payload, r)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doRequest(req)
+	if err != nil {
+		return nil, c.responseError(resp, err)
+	}
+
+	var ar ApiResponse
+	if err := c.decodeResponse(resp, &ar); err != nil {
+		return nil, err
+	}
+	if ar.Status != "ok" {
+		return nil, c.responseError(resp, errors.New("WAF file upload failed"))
+	}
+	return &ar, nil
+}
+
+func (c *Client) DeleteWAFCustomRule(filename string) (*ApiResponse, error) {
+	return c.wafFileCommand("delwafcustomrule", filename)
+}
+
+func (c *Client) DeleteWAFCustomData(filename string) (*ApiResponse, error) {
+	return c.wafFileCommand("delwafcustomdata", filename)
+}
+
+func (c *Client) wafFileCommand(cmd string, filename string) (*ApiResponse, error) {
+	payload := struct {
+		CMD      string `json:"cmd" qs:"-"`
+		Filename string `json:"filename" qs:"filename"`
+	}{
+		CMD:      cmd,
+		Filename: filename,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+// EnableWAFRule assigns the rule set rule to the Virtual Service with index
+// vsindex. The Virtual Service needs WAF enabled through VsWAF for it to
+// take effect.
+func (c *Client) EnableWAFRule(vsindex int, rule string) (*ApiResponse, error) {
+	return c.vsWAFRuleCommand("vsaddwafrule", vsindex, rule)
+}
+
+func (c *Client) DisableWAFRule(vsindex int, rule string) (*ApiResponse, error) {
+	return c.vsWAFRuleCommand("vsremovewafrule", vsindex, rule)
+}
+
+func (c *Client) vsWAFRuleCommand(cmd string, vsindex int, rule string) (*ApiResponse, error) {
+	payload := struct {
+		CMD   string `json:"cmd" qs:"-"`
+		Index int    `json:"vs" qs:"vs"`
+		Rule  string `json:"rule" qs:"rule"`
+	}{
+		CMD:   cmd,
+		Index: vsindex,
+		Rule:  rule,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+func (c *Client) GetWAFSettings() (*WAFSettings, error) {
+	cmd := "getwafsettings"
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	var s WAFSettings
+	if err := c.sendCommand(cmd, payload, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetWAFSettings makes the WAF rule update schedule match s and reports
+// whether anything had to be changed. LastUpdated is ignored.
+func (c *Client) SetWAFSettings(s *WAFSettings) (bool, error) {
+	if s.InstallTime < 0 || s.InstallTime > 23 {
+		return false, errors.New("WAF install time must be an hour between 0 and 23")
+	}
+
+	current, err := c.GetWAFSettings()
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	if current.AutoUpdate != s.AutoUpdate {
+		if _, err := c.wafEnableCommand("setwafautoupdate", s.AutoUpdate); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	if current.AutoInstall != s.AutoInstall {
+		if _, err := c.wafEnableCommand("enablewafautoinstall", s.AutoInstall); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	if current.InstallTime != s.InstallTime {
+		if _, err := c.setWAFInstallTime(s.InstallTime); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+func (c *Client) wafEnableCommand(cmd string, enable bool) (*ApiResponse, error) {
+	payload := struct {
+		CMD    string `json:"cmd" qs:"-"`
+		Enable string `json:"enable" qs:"enable"`
+	}{
+		CMD:    cmd,
+		Enable: formatBool(enable),
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+func (c *Client) setWAFInstallTime(hour int) (*ApiResponse, error) {
+	cmd := "setwafinstalltime"
+	payload := struct {
+		CMD  string `json:"cmd" qs:"-"`
+		Hour int    `json:"hour" qs:"hour"`
+	}{
+		CMD:  cmd,
+		Hour: hour,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
+
+// DownloadWAFRules makes the LoadMaster fetch the latest commercial WAF
+// rules. They are not used until InstallWAFRules is called, unless
+// automatic installation is enabled.
+func (c *Client) DownloadWAFRules() (*ApiResponse, error) {
+	return c.wafCommand("downloadwafrules")
+}
+
+func (c *Client) InstallWAFRules() (*ApiResponse, error) {
+	return c.wafCommand("maninstallwafrules")
+}
+
+func (c *Client) wafCommand(cmd string) (*ApiResponse, error) {
+	payload := struct {
+		CMD string `json:"cmd" qs:"-"`
+	}{
+		CMD: cmd,
+	}
+
+	return c.sendApiCommand(cmd, payload)
+}
@@ -0,0 +1,126 @@
+package lmclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetVsWAF(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{"showvs": "showvs"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			vs, err := client.GetVs(1)
+			ok(t, err)
+
+			equals(t, true, vs.WAF != nil)
+			equals(t, WAFModeDisabled, vs.WAF.InterceptMode)
+			equals(t, false, vs.WAF.Intercept)
+			equals(t, []string{"opnormal", "auditrelevant", "reqdatadisable", "resdatadisable"}, vs.WAF.InterceptOpts)
+			equals(t, []string{"opnormal", "auditnone", "reqdatadisable", "resdatadisable"}, vs.WAF.OwaspOpts)
+		})
+	}
+}
+
+func TestModifyVsWAF(t *testing.T) {
+	testCases := []struct {
+		apiversion int
+		url        string
+		datafile   string
+	}{
+		{2, "/accessv2", "test_data/modvs.json"},
+		{1, "/access/modvs?AlertThreshold=0&AnomalyScoringThreshold=100&BlockingParanoia=2&BodyLimit=1048576&Enable=Y&ExecutingParanoia=3&IPReputationBlocking=Y&Intercept=Y&InterceptMode=2&JSONDLimit=0&OwaspOpts=opnormal%3Bauditrelevant&PCRELimit=0&forcel4=0&forcel7=1&port=&vs=1&vsaddress=", "test_data/modvs.xml"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("apiversion_%d", tc.apiversion), func(t *testing.T) {
+			content, err := ioutil.ReadFile(tc.datafile)
+			ok(t, err)
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				equals(t, req.URL.String(), tc.url)
+				_, err := rw.Write([]byte(content))
+				if err != nil {
+					fmt.Printf("Write failed: %v", err)
+				}
+			}))
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: tc.apiversion}
+
+			_, err = client.ModifyVs(&Vs{
+				Index:  1,
+				Enable: true,
+				WAF: &VsWAF{
+					InterceptMode:           WAFModeOwasp,
+					Intercept:               true,
+					OwaspOpts:               []string{"opnormal", "auditrelevant"},
+					BlockingParanoia:        2,
+					ExecutingParanoia:       3,
+					AnomalyScoringThreshold: 100,
+					IPReputationBlocking:    true,
+					BodyLimit:               1048576,
+				},
+			})
+			ok(t, err)
+		})
+	}
+}
+
+func TestListWAFRules(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{"listwafrules": "listwafrules"}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			rules, err := client.ListWAFRules()
+			ok(t, err)
+
+			equals(t, []string{"G/ip_reputation", "G/generic", "A/wordpress"}, rules.Rules)
+			equals(t, []string{"myrules"}, rules.Custom)
+			equals(t, []string{"badbots.data"}, rules.CustomData)
+		})
+	}
+}
+
+func TestSetWAFSettings(t *testing.T) {
+	for _, apiversion := range []int{2, 1} {
+		t.Run(fmt.Sprintf("apiversion_%d", apiversion), func(t *testing.T) {
+			var cmds []string
+			server := newCommandServer(t, apiversion, map[string]string{
+				"getwafsettings":       "getwafsettings",
+				"setwafautoupdate":     "ok",
+				"enablewafautoinstall": "ok",
+				"setwafinstalltime":    "ok",
+			}, &cmds)
+			defer server.Close()
+			client := Client{HttpClient: server.Client(), ApiKey: "bar", RestUrl: server.URL, Version: apiversion}
+
+			s, err := client.GetWAFSettings()
+			ok(t, err)
+			equals(t, true, s.AutoUpdate)
+			equals(t, 3, s.InstallTime)
+			equals(t, "2023-08-21 03:00:12", s.LastUpdated)
+
+			cmds = nil
+			changed, err := client.SetWAFSettings(&WAFSettings{AutoUpdate: true, InstallTime: 3})
+			ok(t, err)
+			equals(t, false, changed)
+			equals(t, []string{"getwafsettings"}, cmds)
+
+			cmds = nil
+			changed, err = client.SetWAFSettings(&WAFSettings{AutoUpdate: true, AutoInstall: true, InstallTime: 4})
+			ok(t, err)
+			equals(t, true, changed)
+			equals(t, []string{"getwafsettings", "enablewafautoinstall", "setwafinstalltime"}, cmds)
+
+			_, err = client.SetWAFSettings(&WAFSettings{InstallTime: 24})
+			equals(t, "WAF install time must be an hour between 0 and 23", err.Error())
+		})
+	}
+}